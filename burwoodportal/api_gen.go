@@ -0,0 +1,61 @@
+// Code generated by tools/gen-portalapi from api/openapi.json. DO NOT EDIT.
+
+package burwoodportal
+
+import "context"
+
+// listGroupHierarchy - GET /api/group_hierarchy
+// List groups with their departments and projects.
+// Calls fn with each item, following pagination.
+func (c *Client) listGroupHierarchy(ctx context.Context, fn func(item Group) error) error {
+	return forEachListItem(ctx, c, []string{"api", "group_hierarchy"}, fn)
+}
+
+// postGroups - POST /api/group_hierarchy
+// Update the group hierarchy.
+func (c *Client) postGroups(ctx context.Context, body []Group) ([]Group, error) {
+	return do[[]Group](ctx, c, "POST", []string{"api", "group_hierarchy"}, body)
+}
+
+// getProject - GET /api/project/{projectid}
+// Get a project.
+func (c *Client) getProject(ctx context.Context, projectid string) (*Project, error) {
+	result, err := do[Project](ctx, c, "GET", []string{"api", "project", projectid}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// postProject - POST /api/project/{projectid}
+// Create a project, or update it if it exists.
+func (c *Client) postProject(ctx context.Context, projectid string, body Project) (*Project, error) {
+	result, err := do[Project](ctx, c, "POST", []string{"api", "project", projectid}, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// deleteProject - DELETE /api/project/{projectid}
+// Delete a project.
+func (c *Client) deleteProject(ctx context.Context, projectid string) error {
+	_, err := do[noContent](ctx, c, "DELETE", []string{"api", "project", projectid}, nil)
+	return err
+}
+
+// listProjectBudgets - GET /api/project/{projectid}/budgets
+// List a project's budgets, oldest first.
+// Calls fn with each item, following pagination.
+func (c *Client) listProjectBudgets(ctx context.Context, projectid string, fn func(item Allowance) error) error {
+	return forEachListItem(ctx, c, []string{"api", "project", projectid, "budgets"}, fn)
+}
+
+// postBudget - POST /api/{scope}/{entityid}/add_budget
+// Append a budget to an entity.
+func (c *Client) postBudget(ctx context.Context, scope string, entityid string, body Allowance) error {
+	_, err := do[noContent](ctx, c, "POST", []string{"api", scope, entityid, "add_budget"}, body)
+	return err
+}
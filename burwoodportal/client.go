@@ -1,18 +1,24 @@
 package burwoodportal
 
 import (
+	"context"
 	b64 "encoding/base64"
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
+
+	"burwoodportal/burwoodportal/internal/redact"
+	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
 )
 
 // HostURL - Default Portal URL
-const HostURL string = "http://localhost:5000"
+const HostURL string = "https://api.bcs.burwood.com"
 
 // Client -
 type Client struct {
@@ -20,6 +26,20 @@ type Client struct {
 	HTTPClient *http.Client
 	Token      string
 	Auth       AuthStruct
+	// TokenSource supplies OAuth2 bearer tokens. When set it takes
+	// precedence over Token and refreshes tokens as they expire.
+	TokenSource oauth2.TokenSource
+	// StrictDecoding reports responses whose fields differ from the
+	// models as warnings, to catch API contract changes early.
+	StrictDecoding bool
+	// FiscalYearStart is the month the organization's fiscal year starts in,
+	// for end_of_fiscal_year budget expirations. Zero means January.
+	FiscalYearStart time.Month
+	// Transport, if set, sends API requests in place of HTTPClient's
+	// transport, e.g. to record or replay them in tests.
+	Transport http.RoundTripper
+	// baseURL is HostURL parsed, including any base path.
+	baseURL *url.URL
 }
 
 // AuthStruct -
@@ -33,18 +53,54 @@ type AuthResponse struct {
 	Token string `json:"token"`
 }
 
+// APIError - Non-2xx response returned by the portal REST API.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("status: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// RequestError - Failed API call, tagged with the X-Request-ID it was sent with.
+// Err is an *APIError when the portal answered with an error status.
+type RequestError struct {
+	RequestID string
+	Err       error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%s (request ID: %s)", e.Err, e.RequestID)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
 // NewClient -
-func NewClient(host, username, password *string) (*Client, error) {
+// A nil httpClient uses default transport settings, see NewHTTPClient.
+func NewClient(ctx context.Context, host, username, password *string, httpClient *http.Client) (*Client, error) {
 	c := Client{
-		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		HTTPClient: httpClient,
 		// Default burwood portal URL
 		HostURL: HostURL,
 	}
 
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{Timeout: DefaultRequestTimeout}
+	}
+
 	if host != nil {
 		c.HostURL = *host
 	}
 
+	baseURL, err := ParseHostURL(c.HostURL)
+	if err != nil {
+		return nil, err
+	}
+	c.baseURL = baseURL
+
 	// If username or password not provided, return empty client
 	if username == nil || password == nil {
 		return &c, nil
@@ -55,7 +111,7 @@ func NewClient(host, username, password *string) (*Client, error) {
 		Password: *password,
 	}
 
-	ar, err := c.SignIn()
+	ar, err := c.SignIn(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -65,162 +121,172 @@ func NewClient(host, username, password *string) (*Client, error) {
 	return &c, nil
 }
 
-// SignIn - Get a new token for user
-func (c *Client) SignIn() (*AuthResponse, error) {
-	if c.Auth.Username == "" || c.Auth.Password == "" {
-		return nil, fmt.Errorf("define username and password")
-	}
-
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/token", c.HostURL), nil)
+// ParseHostURL - Parse and validate a portal host URL.
+// Any path on the URL is kept as a base path for all endpoints,
+// e.g. https://gateway.example.com/portal.
+func ParseHostURL(host string) (*url.URL, error) {
+	u, err := url.Parse(host)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid host %q: %w", host, err)
 	}
 
-	authString := fmt.Sprintf("%s:%s", c.Auth.Username, c.Auth.Password)
-	encodedAuthString := b64.StdEncoding.EncodeToString([]byte(authString))
-	req.Header.Add("Authorization", fmt.Sprintf("Basic %s", encodedAuthString))
-
-	body, err := c.doRequest(req, nil)
-	if err != nil {
-		return nil, err
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid host %q: scheme must be http or https", host)
 	}
-
-	ar := AuthResponse{}
-	err = json.Unmarshal(body, &ar)
-	if err != nil {
-		log.Println(err)
-		return nil, err
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid host %q: missing hostname", host)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, fmt.Errorf("invalid host %q: query strings and fragments are not allowed", host)
 	}
 
-	return &ar, nil
+	return u, nil
 }
 
-func (c *Client) doRequest(req *http.Request, authToken *string) ([]byte, error) {
-	token := c.Token
+// endpointURL builds the URL of an API endpoint below the host's base path.
+// Each segment is path-escaped, so IDs can be passed as-is.
+func (c *Client) endpointURL(segments ...string) string {
+	u := *c.baseURL
 
-	if authToken != nil {
-		token = *authToken
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = url.PathEscape(segment)
 	}
 
-	req.Header.Set("x-access-token", token)
-	req.Header.Set("content-type", "application/json")
+	u.Path = strings.TrimSuffix(c.baseURL.Path, "/") + "/" + strings.Join(segments, "/")
+	u.RawPath = strings.TrimSuffix(c.baseURL.EscapedPath(), "/") + "/" + strings.Join(escaped, "/")
 
-	res, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return nil, err
+	return u.String()
+}
+
+// NewClientWithToken - Client authenticating with a pre-issued API token instead of signing in
+func NewClientWithToken(ctx context.Context, host, token *string, httpClient *http.Client) (*Client, error) {
+	if token == nil || *token == "" {
+		return nil, fmt.Errorf("define token")
 	}
-	defer res.Body.Close()
 
-	body, err := ioutil.ReadAll(res.Body)
+	c, err := NewClient(ctx, host, nil, nil, httpClient)
 	if err != nil {
 		return nil, err
 	}
 
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("status: %d, body: %s", res.StatusCode, body)
-	}
+	c.Token = *token
 
-	return body, err
+	return c, nil
 }
 
-// Reusable function to make a GET request on an API endpoint.
-func (c *Client) getEndpointList(endpoint string) ([]map[string]interface{}, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/%s", c.HostURL, endpoint), nil)
+// NewClientWithOAuth - Client authenticating with the OAuth2 client-credentials grant
+func NewClientWithOAuth(ctx context.Context, host *string, config *clientcredentials.Config, httpClient *http.Client) (*Client, error) {
+	if config == nil || config.TokenURL == "" || config.ClientID == "" || config.ClientSecret == "" {
+		return nil, fmt.Errorf("define oauth token URL, client ID and client secret")
+	}
+
+	c, err := NewClient(ctx, host, nil, nil, httpClient)
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := c.doRequest(req, nil)
+	// The token source outlives the provider configure call, so it must not be
+	// bound to its context. Token requests share the client's HTTP settings.
+	tokenCtx := context.WithValue(context.Background(), oauth2.HTTPClient, c.HTTPClient)
+	c.TokenSource = config.TokenSource(tokenCtx)
 
-	// Unmarshal response JSON into a map data structure
-	bodyMap := make([]map[string]interface{}, 0)
-	err = json.Unmarshal(body, &bodyMap)
-	if bodyMap == nil {
-		return nil, err
-	}
-	if err != nil {
+	// Fetch the first token now so bad credentials fail at configure time.
+	if _, err := c.TokenSource.Token(); err != nil {
 		return nil, err
 	}
 
-	return bodyMap, nil
+	return c, nil
 }
 
-// Reusable function to make a GET request on an API endpoint.
-func (c *Client) getEndpointSingleItem(endpoint string) (map[string]interface{}, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/%s", c.HostURL, endpoint), nil)
-	if err != nil {
-		return nil, err
+// SignIn - Get a new token for user
+func (c *Client) SignIn(ctx context.Context) (*AuthResponse, error) {
+	if c.Auth.Username == "" || c.Auth.Password == "" {
+		return nil, fmt.Errorf("define username and password")
 	}
 
-	body, err := c.doRequest(req, nil)
+	authString := fmt.Sprintf("%s:%s", c.Auth.Username, c.Auth.Password)
+	encodedAuthString := b64.StdEncoding.EncodeToString([]byte(authString))
 
-	// Unmarshal response JSON into a map data structure
-	bodyMap := make(map[string]interface{}, 0)
-	err = json.Unmarshal(body, &bodyMap)
-	if bodyMap == nil {
-		return nil, err
-	}
+	ar, err := do[AuthResponse](ctx, c, "POST", []string{"token"}, nil, withHeader("Authorization", fmt.Sprintf("Basic %s", encodedAuthString)))
 	if err != nil {
+		tflog.Error(ctx, "Unable to sign in", map[string]interface{}{"error": err.Error()})
 		return nil, err
 	}
 
-	return bodyMap, nil
+	return &ar, nil
 }
 
+// doRequestWithHeader sends an authenticated request, returning the
+// response body and headers. Non-200 responses are returned as *APIError.
+func (c *Client) doRequestWithHeader(req *http.Request, authToken *string) ([]byte, http.Header, error) {
+	token := c.Token
 
-// Reusable function to make a GET request on an API endpoint.
-func (c *Client) postEndpoint(endpoint string, postBody map[string]interface{}) ([]map[string]interface{}, error) {
-	postBodyMarshaled, err := json.Marshal(postBody)
-	if err != nil {
-		return nil, err
+	if authToken != nil {
+		token = *authToken
 	}
 
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/%s", c.HostURL, endpoint), strings.NewReader(string(postBodyMarshaled)))
-	if err != nil {
-		return nil, err
+	if c.TokenSource != nil {
+		t, err := c.TokenSource.Token()
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to obtain oauth token: %w", err)
+		}
+		t.SetAuthHeader(req)
+	} else {
+		req.Header.Set("x-access-token", token)
 	}
+	req.Header.Set("content-type", "application/json")
 
-	responseBody, err := c.doRequest(req, nil)
-
-	// Unmarshal response JSON into a map data structure
-	responseBodyMap := make([]map[string]interface{}, 0)
-	err = json.Unmarshal(responseBody, &responseBodyMap)
-	if responseBodyMap == nil {
-		return nil, err
-	}
+	// Lets the portal team correlate our calls with their server logs.
+	requestID, err := uuid.GenerateUUID()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	req.Header.Set("X-Request-ID", requestID)
+
+	ctx := tflog.SetField(req.Context(), "request_id", requestID)
+	ctx = tflog.SetField(ctx, "http_method", req.Method)
+	ctx = tflog.SetField(ctx, "http_url", req.URL.String())
+	tflog.Debug(ctx, "Sending portal API request", map[string]interface{}{"http_request_headers": redact.Headers(req.Header)})
+	if req.GetBody != nil {
+		if requestBody, err := req.GetBody(); err == nil {
+			if b, err := ioutil.ReadAll(requestBody); err == nil {
+				tflog.Trace(ctx, "Portal API request body", map[string]interface{}{"http_request_body": redact.JSON(b)})
+			}
+		}
 	}
 
-	return responseBodyMap, nil
-}
-
-func (c *Client) postGroups(endpoint string, postBody []Group) ([]Group, error) {
-	postBodyMarshaled, err := json.Marshal(postBody)
+	start := time.Now()
+	res, err := c.httpClient().Do(req)
 	if err != nil {
-		return nil, err
+		tflog.Debug(ctx, "Portal API request failed", map[string]interface{}{"error": err.Error(), "duration_ms": time.Since(start).Milliseconds()})
+		return nil, nil, &RequestError{RequestID: requestID, Err: err}
 	}
+	defer res.Body.Close()
 
-	processedBody := strings.NewReader(string(postBodyMarshaled))
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/%s", c.HostURL, endpoint), processedBody)
+	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return nil, err
+		return nil, nil, &RequestError{RequestID: requestID, Err: err}
 	}
-	
 
-	responseBody, err := c.doRequest(req, nil)
+	tflog.Debug(ctx, "Received portal API response", map[string]interface{}{"http_status": res.StatusCode, "duration_ms": time.Since(start).Milliseconds()})
+	tflog.Trace(ctx, "Portal API response body", map[string]interface{}{"http_response_body": redact.JSON(body)})
 
-	// Unmarshal response JSON into a map data structure
-	responseBodyMap := make([]Group, 0)
-	err = json.Unmarshal(responseBody, &responseBodyMap)
-	if responseBodyMap == nil {
-		return nil, err
-	}
-	if err != nil {
-		return nil, err
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, &RequestError{RequestID: requestID, Err: &APIError{StatusCode: res.StatusCode, Body: string(body)}}
 	}
 
-	return responseBodyMap, nil
+	return body, res.Header, nil
 }
 
+// httpClient returns HTTPClient, using Transport instead of its transport if set.
+func (c *Client) httpClient() *http.Client {
+	if c.Transport == nil {
+		return c.HTTPClient
+	}
 
+	httpClient := *c.HTTPClient
+	httpClient.Transport = c.Transport
+
+	return &httpClient
+}
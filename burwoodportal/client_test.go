@@ -0,0 +1,184 @@
+package burwoodportal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"burwoodportal/burwoodportal/portaltest"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+func newTestClient(t *testing.T, server *portaltest.Server) *Client {
+	t.Helper()
+
+	host, username, password := server.URL, portaltest.Username, portaltest.Password
+	c, err := NewClient(context.Background(), &host, &username, &password, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestClientProjectLifecycle(t *testing.T) {
+	server := portaltest.NewServer()
+	defer server.Close()
+	c := newTestClient(t, server)
+	ctx := context.Background()
+
+	if _, err := c.postProject(ctx, "tf-acc-1", Project{ProjectName: "Test"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.postBudget(ctx, "project", "tf-acc-1", Allowance{Amount: testMoney("100"), BillingAccountID: "billing-1", State: "Active"}); err != nil {
+		t.Fatal(err)
+	}
+
+	project, err := c.getProject(ctx, "tf-acc-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if project.PaidBillingAccount != "billing-1" || project.TotalBudget.String() != "100" {
+		t.Errorf("unexpected project %+v", project)
+	}
+	if project.DepartmentID != portaltest.UnaffiliatedDepartmentID {
+		t.Errorf("project in department %q, want the unaffiliated department", project.DepartmentID)
+	}
+
+	if err := c.deleteProject(ctx, "tf-acc-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := server.Project("tf-acc-1"); ok {
+		t.Error("project still exists after delete")
+	}
+}
+
+func TestClientListPagination(t *testing.T) {
+	server := portaltest.NewServer()
+	defer server.Close()
+	server.PageSize = 2
+	c := newTestClient(t, server)
+	ctx := context.Background()
+
+	server.PutProject(portaltest.Project{ProjectID: "tf-acc-1"})
+	for i := 0; i < 5; i++ {
+		if err := c.postBudget(ctx, "project", "tf-acc-1", Allowance{Amount: testMoney("1")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	budgets := 0
+	err := c.listProjectBudgets(ctx, "tf-acc-1", func(Allowance) error {
+		budgets++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if budgets != 5 {
+		t.Errorf("got %d budgets, want 5", budgets)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	server := portaltest.NewServer()
+	defer server.Close()
+	c := newTestClient(t, server)
+	ctx := context.Background()
+
+	server.ExpireTokens()
+	_, err := c.getProject(ctx, "tf-acc-1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expired token: got %v, want HTTP 401", err)
+	}
+
+	c = newTestClient(t, server)
+	server.InjectFault(portaltest.Fault{Path: "/api/project/", Status: http.StatusServiceUnavailable, Times: 1})
+	_, err = c.getProject(ctx, "tf-acc-1")
+	var requestErr *RequestError
+	if !errors.As(err, &requestErr) || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("injected fault: got %v, want HTTP 503", err)
+	}
+
+	requests := server.Requests()
+	last := requests[len(requests)-1]
+	if last.Header.Get("X-Request-ID") != requestErr.RequestID {
+		t.Errorf("error reports request ID %q, server saw %q", requestErr.RequestID, last.Header.Get("X-Request-ID"))
+	}
+
+	c.HTTPClient.Timeout = 50 * time.Millisecond
+	server.InjectFault(portaltest.Fault{Latency: 200 * time.Millisecond, Times: 1})
+	if _, err := c.getProject(ctx, "tf-acc-1"); err == nil {
+		t.Error("slow response: want timeout error")
+	}
+}
+
+func TestClientOAuth(t *testing.T) {
+	server := portaltest.NewServer()
+	defer server.Close()
+	ctx := context.Background()
+	server.PutProject(portaltest.Project{ProjectID: "tf-acc-1"})
+
+	host := server.URL
+	config := &clientcredentials.Config{
+		TokenURL:     server.URL + "/oauth/token",
+		ClientID:     portaltest.ClientID,
+		ClientSecret: portaltest.ClientSecret,
+	}
+
+	tokenRequests := func() []portaltest.RecordedRequest {
+		var requests []portaltest.RecordedRequest
+		for _, request := range server.Requests() {
+			if request.Path == "/oauth/token" {
+				requests = append(requests, request)
+			}
+		}
+		return requests
+	}
+
+	badSecret := *config
+	badSecret.ClientSecret = "wrong"
+	if _, err := NewClientWithOAuth(ctx, &host, &badSecret, nil); err == nil {
+		t.Error("invalid client secret: want error at configure time")
+	}
+
+	c, err := NewClientWithOAuth(ctx, &host, config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := len(tokenRequests())
+	for i := 0; i < 2; i++ {
+		if _, err := c.getProject(ctx, "tf-acc-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(tokenRequests()) - before; got != 0 {
+		t.Errorf("valid token: got %d token requests, want the configure time token reused", got)
+	}
+
+	requests := server.Requests()
+	last := requests[len(requests)-1]
+	if !strings.HasPrefix(last.Header.Get("Authorization"), "Bearer ") || last.Header.Get("x-access-token") != "" {
+		t.Errorf("API request sent Authorization %q and x-access-token %q, want only a bearer token",
+			last.Header.Get("Authorization"), last.Header.Get("x-access-token"))
+	}
+
+	// oauth2 refreshes tokens expiring within 10 seconds, so a shorter
+	// lifetime makes every call refresh.
+	server.TokenTTL = 5 * time.Second
+	c, err = NewClientWithOAuth(ctx, &host, config, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before = len(tokenRequests())
+	if _, err := c.getProject(ctx, "tf-acc-1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(tokenRequests()) - before; got != 1 {
+		t.Errorf("expiring token: got %d token requests, want 1 refresh", got)
+	}
+}
@@ -0,0 +1,189 @@
+package burwoodportal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// contractMismatch - Differences between a response and the model it decodes into.
+type contractMismatch struct {
+	Unknown []string
+	Missing []string
+}
+
+// contractCollector gathers mismatches per endpoint during one CRUD call.
+type contractCollector struct {
+	mu         sync.Mutex
+	endpoints  []string
+	mismatches map[string]map[string]bool
+}
+
+type contractCollectorKey struct{}
+
+// withContractCollector returns a context collecting mismatches for one CRUD call.
+func withContractCollector(ctx context.Context) (context.Context, *contractCollector) {
+	collector := &contractCollector{mismatches: map[string]map[string]bool{}}
+
+	return context.WithValue(ctx, contractCollectorKey{}, collector), collector
+}
+
+// checkContract compares a JSON response with the fields of the model type
+// it was decoded into and records any mismatch for the current CRUD call.
+func (c *Client) checkContract(ctx context.Context, endpoint string, data []byte, model reflect.Type) {
+	if !c.StrictDecoding {
+		return
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return
+	}
+
+	mismatch := &contractMismatch{}
+	compareFields(decoded, model, "", mismatch)
+	if len(mismatch.Unknown) == 0 && len(mismatch.Missing) == 0 {
+		return
+	}
+
+	tflog.Warn(ctx, "Portal API response does not match the provider's model", map[string]interface{}{
+		"endpoint":       endpoint,
+		"unknown_fields": mismatch.Unknown,
+		"missing_fields": mismatch.Missing,
+	})
+
+	if collector, ok := ctx.Value(contractCollectorKey{}).(*contractCollector); ok {
+		collector.add(endpoint, mismatch)
+	}
+}
+
+// compareFields walks value alongside model, recording object keys the model
+// doesn't have as unknown and model fields the object lacks as missing.
+// Fields tagged omitempty are optional and never reported missing.
+func compareFields(value interface{}, model reflect.Type, prefix string, mismatch *contractMismatch) {
+	for model.Kind() == reflect.Ptr {
+		model = model.Elem()
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		if model.Kind() != reflect.Slice && model.Kind() != reflect.Array {
+			return
+		}
+		for _, item := range v {
+			compareFields(item, model.Elem(), prefix+"[]", mismatch)
+		}
+	case map[string]interface{}:
+		if model.Kind() != reflect.Struct {
+			return
+		}
+
+		known := map[string]bool{}
+		for i := 0; i < model.NumField(); i++ {
+			field := model.Field(i)
+			name, optional := jsonFieldName(field)
+			if name == "" {
+				continue
+			}
+			known[name] = true
+
+			fieldValue, present := v[name]
+			if !present {
+				if !optional {
+					mismatch.Missing = append(mismatch.Missing, joinFieldPath(prefix, name))
+				}
+				continue
+			}
+			compareFields(fieldValue, field.Type, joinFieldPath(prefix, name), mismatch)
+		}
+
+		for key := range v {
+			if !known[key] {
+				mismatch.Unknown = append(mismatch.Unknown, joinFieldPath(prefix, key))
+			}
+		}
+	}
+}
+
+// jsonFieldName returns the JSON key of a struct field, empty if it isn't encoded.
+func jsonFieldName(field reflect.StructField) (name string, optional bool) {
+	if field.PkgPath != "" {
+		return "", false
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	if name == "" {
+		name = field.Name
+	}
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			optional = true
+		}
+	}
+
+	return name, optional
+}
+
+func joinFieldPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	return prefix + "." + name
+}
+
+func (cc *contractCollector) add(endpoint string, mismatch *contractMismatch) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	fields, ok := cc.mismatches[endpoint]
+	if !ok {
+		fields = map[string]bool{}
+		cc.mismatches[endpoint] = fields
+		cc.endpoints = append(cc.endpoints, endpoint)
+	}
+	for _, name := range mismatch.Unknown {
+		fields["unknown: "+name] = true
+	}
+	for _, name := range mismatch.Missing {
+		fields["missing: "+name] = true
+	}
+}
+
+func (cc *contractCollector) diagnostics() diag.Diagnostics {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	var diags diag.Diagnostics
+	for _, endpoint := range cc.endpoints {
+		fields := make([]string, 0, len(cc.mismatches[endpoint]))
+		for field := range cc.mismatches[endpoint] {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Portal API response does not match the provider's model",
+			Detail: fmt.Sprintf("The response from %s differs from what the provider expects:\n  %s\n\n"+
+				"Unknown fields are ignored and missing fields are read as empty values, which can cause drift. "+
+				"The portal API contract may have changed; please report this to the provider maintainers.",
+				endpoint, strings.Join(fields, "\n  ")),
+		})
+	}
+
+	return diags
+}
@@ -0,0 +1,46 @@
+package burwoodportal
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"golang.org/x/text/currency"
+)
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// validateCurrency checks an ISO 4217 currency code such as "USD" or "EUR".
+// Codes must be upper case, as the portal returns them, so reads don't diff.
+func validateCurrency(code string) error {
+	if !currencyCodePattern.MatchString(code) {
+		return fmt.Errorf("%q is not an upper case, three letter ISO 4217 currency code", code)
+	}
+	if _, err := currency.ParseISO(code); err != nil {
+		return fmt.Errorf("%q is not an ISO 4217 currency code", code)
+	}
+
+	return nil
+}
+
+// currencyValidator - Plan time validation of currency attributes.
+type currencyValidator struct{}
+
+func (v currencyValidator) Description(ctx context.Context) string {
+	return "value must be an ISO 4217 currency code"
+}
+
+func (v currencyValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v currencyValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if err := validateCurrency(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Currency", err.Error())
+	}
+}
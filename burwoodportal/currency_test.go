@@ -0,0 +1,17 @@
+package burwoodportal
+
+import "testing"
+
+func TestValidateCurrency(t *testing.T) {
+	for _, code := range []string{"USD", "EUR", "GBP", "JPY"} {
+		if err := validateCurrency(code); err != nil {
+			t.Errorf("%s: %s", code, err)
+		}
+	}
+
+	for _, code := range []string{"", "usd", "Eur", "US", "USDX", "ABC", "$"} {
+		if err := validateCurrency(code); err == nil {
+			t.Errorf("%q accepted", code)
+		}
+	}
+}
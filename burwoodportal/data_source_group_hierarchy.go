@@ -3,108 +3,159 @@ package burwoodportal
 import (
 	"context"
 	"fmt"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"strconv"
 	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
+// Because this endpoint is a nesting doll, the hierarchy is one list of
+// nested objects. Nested attributes would need protocol 6, which the
+// plugin SDK half of the provider can't serve.
+var projectDataSourceType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"projectid":   types.StringType,
+	"projectname": types.StringType,
+}}
 
-// Because this endpoint is a nesting doll,
-// break up the schema into list types.
-var projectDataSourceSchema = &schema.Resource{
-	Schema: map[string]*schema.Schema{
-		"projectid": &schema.Schema{
-			Type:     schema.TypeString,
-			Computed: true,
-			Description: "GCP project id",
-		},
-	},
+var departmentDataSourceType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"departmentname": types.StringType,
+	"departmentid":   types.StringType,
+	"projects":       types.ListType{ElemType: projectDataSourceType},
+}}
+
+var groupDataSourceType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"groupname":   types.StringType,
+	"groupid":     types.StringType,
+	"departments": types.ListType{ElemType: departmentDataSourceType},
+}}
+
+// hierarchyDataSource - burwoodportal_hierarchy, the group, department and project tree.
+type hierarchyDataSource struct {
+	client *Client
 }
 
-var departmentDataSourceSchema = &schema.Resource{
-	Schema: map[string]*schema.Schema{
-		"departmentname": &schema.Schema{
-			Type:     schema.TypeString,
-			Computed: true,
-			Description: "Department name as it appears in the portal.",
-		},
-		"departmentid": &schema.Schema{
-			Type:     schema.TypeString,
-			Computed: true,
-			Description: "Unique department ID used under the hood to relate the department to projects and groups.",
-		},
-		"projects": &schema.Schema{
-			Type:     schema.TypeList,
-			Elem:     projectDataSourceSchema,
-			Computed: true,
-			Description: "List of projects. See project schema.",
-		},
-	},
+type hierarchyDataSourceModel struct {
+	ID     types.String           `tfsdk:"id"`
+	Groups []groupDataSourceModel `tfsdk:"groups"`
+}
+
+type groupDataSourceModel struct {
+	GroupName   types.String                `tfsdk:"groupname"`
+	GroupID     types.String                `tfsdk:"groupid"`
+	Departments []departmentDataSourceModel `tfsdk:"departments"`
+}
+
+type departmentDataSourceModel struct {
+	DepartmentName types.String             `tfsdk:"departmentname"`
+	DepartmentID   types.String             `tfsdk:"departmentid"`
+	Projects       []projectDataSourceModel `tfsdk:"projects"`
+}
+
+type projectDataSourceModel struct {
+	ProjectID   types.String `tfsdk:"projectid"`
+	ProjectName types.String `tfsdk:"projectname"`
+}
+
+// NewHierarchyDataSource - burwoodportal_hierarchy factory.
+func NewHierarchyDataSource() datasource.DataSource {
+	return &hierarchyDataSource{}
 }
 
-var groupDataSourceSchema = map[string]*schema.Schema{
-	"groups": &schema.Schema{
-		Type:     schema.TypeList,
-		Computed: true,
-		Elem: &schema.Resource{
-			Schema: map[string]*schema.Schema{
-				"groupname": &schema.Schema{
-					Type:     schema.TypeString,
-					Computed: true,
-					Description: "Group name as it appears in the portal.",
-				},
-				"groupid": &schema.Schema{
-					Type:     schema.TypeString,
-					Computed: true,
-					Description: "Unique group ID used under the hood to relate groups to departments.",
-				},
-				"departments": &schema.Schema {
-					Type: schema.TypeList,
-					Elem: departmentDataSourceSchema,
-					Computed: true,
-					Description: "List of departments. Projects are nested underneath departments. See department schema.",
-				},
+func (ds *hierarchyDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_hierarchy"
+}
+
+func (ds *hierarchyDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Groups, their departments and the departments' projects, as shown in the portal.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "Time of the read, as a unix timestamp.",
+			},
+			"groups": schema.ListAttribute{
+				Computed:    true,
+				ElementType: groupDataSourceType,
+				Description: "List of groups, each with groupname, groupid and departments. Departments have departmentname, departmentid and projects; projects have projectid and projectname.",
 			},
 		},
-	},
+	}
 }
 
-// Define group schema.
-func dataSourceGroupHierarchy() *schema.Resource {
-	return &schema.Resource{
-		ReadContext: dataSourcehierarchyRead,
-		Schema:      groupDataSourceSchema,
+func (ds *hierarchyDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Not configured yet during validation.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data", fmt.Sprintf("Expected *Client, got %T.", req.ProviderData))
+		return
 	}
+
+	ds.client = client
 }
 
 // Read groups from the portal API endpoint.
-func dataSourcehierarchyRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	// Use this slice for detailed terraform diagnostics
-	var diags diag.Diagnostics
-
-	c := m.(*Client)
-	groups, err := c.getEndpointList("api/group_hierarchy")
-
-	if err != nil || groups == nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error Retrieving Groups",
-			Detail:   fmt.Sprintf("Groups %v", groups),
-		})
+func (ds *hierarchyDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx, collector := withContractCollector(ctx)
+	defer func() {
+		resp.Diagnostics.Append(frameworkDiagnostics(collector.diagnostics())...)
+	}()
 
-		return diag.FromErr(err)
-	}
+	groups := []Group{}
+	err := ds.client.listGroupHierarchy(ctx, func(group Group) error {
+		groups = append(groups, group)
+		return nil
+	})
 
-	if err := d.Set("groups", groups); err != nil {
-		return diag.FromErr(err)
+	if err != nil {
+		resp.Diagnostics.AddError("Error Retrieving Groups", errorDetail(err))
+
+		return
 	}
 
 	// always run
-	// Set to unix time to force the resource to reapply every time
-	d.SetId(strconv.FormatInt(time.Now().Unix(), 10))
+	// Set to unix time to force the data source to re-read every time
+	state := hierarchyDataSourceModel{
+		ID:     types.StringValue(strconv.FormatInt(time.Now().Unix(), 10)),
+		Groups: flattenGroupsModel(groups),
+	}
 
-	return diags
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 }
 
+// Convert groups into the model of the groups attribute.
+func flattenGroupsModel(groups []Group) []groupDataSourceModel {
+	flattenedGroups := make([]groupDataSourceModel, 0, len(groups))
+	for _, group := range groups {
+		departments := make([]departmentDataSourceModel, 0, len(group.Departments))
+		for _, department := range group.Departments {
+			projects := make([]projectDataSourceModel, 0, len(department.Projects))
+			for _, project := range department.Projects {
+				projects = append(projects, projectDataSourceModel{
+					ProjectID:   types.StringValue(project.ProjectID),
+					ProjectName: types.StringValue(project.ProjectName),
+				})
+			}
 
+			departments = append(departments, departmentDataSourceModel{
+				DepartmentName: types.StringValue(department.DepartmentName),
+				DepartmentID:   types.StringValue(department.DepartmentID),
+				Projects:       projects,
+			})
+		}
+
+		flattenedGroups = append(flattenedGroups, groupDataSourceModel{
+			GroupName:   types.StringValue(group.GroupName),
+			GroupID:     types.StringValue(group.GroupID),
+			Departments: departments,
+		})
+	}
+
+	return flattenedGroups
+}
@@ -0,0 +1,68 @@
+package burwoodportal
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccDataSourceHierarchy_basic(t *testing.T) {
+	portal := newTestAccPortal(t)
+	projectID := testAccProjectID()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckProjectDestroy(t, portal),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceHierarchyConfig(portal, projectID, portal.DepartmentIDs[0]),
+				Check:  testAccCheckHierarchyProject("data.burwoodportal_hierarchy.test", portal.DepartmentIDs[0], projectID),
+			},
+			// Moving the project shows up under its new department.
+			{
+				Config: testAccDataSourceHierarchyConfig(portal, projectID, portal.DepartmentIDs[1]),
+				Check:  testAccCheckHierarchyProject("data.burwoodportal_hierarchy.test", portal.DepartmentIDs[1], projectID),
+			},
+		},
+	})
+}
+
+func testAccDataSourceHierarchyConfig(portal *testAccPortal, projectID, departmentID string) string {
+	return testAccProjectConfig(portal, testAccProject{ProjectID: projectID, DepartmentID: departmentID}) + `
+data "burwoodportal_hierarchy" "test" {
+  depends_on = [burwoodportal_projects.test]
+}
+`
+}
+
+// testAccCheckHierarchyProject checks that the hierarchy lists the project
+// under the department, and nowhere else.
+func testAccCheckHierarchyProject(resourceName, departmentID, projectID string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("%s not found in state", resourceName)
+		}
+		attrs := rs.Primary.Attributes
+
+		found := []string{}
+		for g := 0; attrs[fmt.Sprintf("groups.%d.groupid", g)] != ""; g++ {
+			for d := 0; attrs[fmt.Sprintf("groups.%d.departments.%d.departmentid", g, d)] != ""; d++ {
+				department := fmt.Sprintf("groups.%d.departments.%d", g, d)
+				for p := 0; attrs[fmt.Sprintf("%s.projects.%d.projectid", department, p)] != ""; p++ {
+					if attrs[fmt.Sprintf("%s.projects.%d.projectid", department, p)] == projectID {
+						found = append(found, attrs[department+".departmentid"])
+					}
+				}
+			}
+		}
+
+		if len(found) != 1 || found[0] != departmentID {
+			return fmt.Errorf("project %s listed under departments %v, want [%s]", projectID, found, departmentID)
+		}
+
+		return nil
+	}
+}
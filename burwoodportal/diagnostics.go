@@ -0,0 +1,131 @@
+package burwoodportal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// errorDiagnostic builds an error diagnostic from a failed operation.
+// The detail carries the API error (status code and body when the portal
+// rejected the request) and path points Terraform at the offending attribute.
+// A nil path attaches the diagnostic to the resource as a whole.
+func errorDiagnostic(summary string, err error, path cty.Path) diag.Diagnostic {
+	return diag.Diagnostic{
+		Severity:      diag.Error,
+		Summary:       summary,
+		Detail:        errorDetail(err),
+		AttributePath: path,
+	}
+}
+
+// errorDetail describes err for a diagnostic. The X-Request-ID of the failed
+// call is appended so issues can be correlated with the portal's server logs.
+func errorDetail(err error) string {
+	if err == nil {
+		return "The portal API returned an empty response."
+	}
+
+	detail := err.Error()
+
+	var apiErr *APIError
+	var requestErr *RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		detail = fmt.Sprintf("The portal API responded with HTTP %d: %s", apiErr.StatusCode, apiErr.Body)
+	case errors.As(err, &requestErr):
+		detail = requestErr.Err.Error()
+	}
+
+	if errors.As(err, &requestErr) {
+		detail = fmt.Sprintf("%s\n\nRequest ID: %s", detail, requestErr.RequestID)
+	}
+
+	return detail
+}
+
+// timeoutDiagnostics explains an operation cut short by its timeout. ctx is
+// the context carrying the timeout and diags those of the operation, returned
+// unchanged if it didn't expire. Otherwise the errors, which the expired
+// context caused, are replaced by a single one naming the first of them.
+func timeoutDiagnostics(ctx context.Context, operation string, timeout time.Duration, diags fwdiag.Diagnostics) fwdiag.Diagnostics {
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return diags
+	}
+
+	var explained fwdiag.Diagnostics
+	var first fwdiag.Diagnostic
+	for _, d := range diags {
+		if d.Severity() != fwdiag.SeverityError {
+			explained.Append(d)
+		} else if first == nil {
+			first = d
+		}
+	}
+
+	detail := fmt.Sprintf("The %s operation did not finish within its %s timeout. The portal may still complete it. "+
+		"Raise the timeout with the timeouts block, e.g. timeouts { %s = \"30m\" }.", operation, timeout, operation)
+	if first != nil {
+		detail = fmt.Sprintf("%s\n\n%s: %s", detail, first.Summary(), first.Detail())
+	}
+	explained.AddError("Timeout Exceeded", detail)
+
+	return explained
+}
+
+// frameworkDiagnostics converts plugin SDK diagnostics for the plugin framework,
+// so both halves of the provider can share code returning diag.Diagnostics.
+func frameworkDiagnostics(diags diag.Diagnostics) fwdiag.Diagnostics {
+	var converted fwdiag.Diagnostics
+
+	for _, d := range diags {
+		attributePath, hasPath := frameworkPath(d.AttributePath)
+		switch {
+		case d.Severity == diag.Error && hasPath:
+			converted.AddAttributeError(attributePath, d.Summary, d.Detail)
+		case d.Severity == diag.Error:
+			converted.AddError(d.Summary, d.Detail)
+		case hasPath:
+			converted.AddAttributeWarning(attributePath, d.Summary, d.Detail)
+		default:
+			converted.AddWarning(d.Summary, d.Detail)
+		}
+	}
+
+	return converted
+}
+
+// frameworkPath converts an attribute path of names and list indexes.
+func frameworkPath(p cty.Path) (path.Path, bool) {
+	if len(p) == 0 {
+		return path.Empty(), false
+	}
+
+	var converted path.Path
+	for i, step := range p {
+		switch s := step.(type) {
+		case cty.GetAttrStep:
+			if i == 0 {
+				converted = path.Root(s.Name)
+			} else {
+				converted = converted.AtName(s.Name)
+			}
+		case cty.IndexStep:
+			if i == 0 || s.Key.Type() != cty.Number {
+				return path.Empty(), false
+			}
+			index, _ := s.Key.AsBigFloat().Int64()
+			converted = converted.AtListIndex(int(index))
+		default:
+			return path.Empty(), false
+		}
+	}
+
+	return converted, true
+}
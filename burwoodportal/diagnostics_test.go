@@ -0,0 +1,35 @@
+package burwoodportal
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+func TestTimeoutDiagnostics(t *testing.T) {
+	var diags fwdiag.Diagnostics
+	diags.AddAttributeError(path.Root("paidbillingaccount"), "Timed Out Waiting for Billing Account Switch", "Project p still shows paidbillingaccount \"a\".")
+	diags.AddError("Error Retrieving Project p", "context deadline exceeded")
+	diags.AddWarning("Unexpected Response Fields", "extra")
+
+	if got := timeoutDiagnostics(context.Background(), "create", time.Minute, diags); len(got) != len(diags) {
+		t.Errorf("diagnostics changed without a timeout: %v", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	got := timeoutDiagnostics(ctx, "create", time.Minute, diags)
+	if got.ErrorsCount() != 1 || got.WarningsCount() != 1 {
+		t.Fatalf("got %d errors and %d warnings, want one of each: %v", got.ErrorsCount(), got.WarningsCount(), got)
+	}
+	timeoutErr := got.Errors()[0]
+	if timeoutErr.Summary() != "Timeout Exceeded" || !strings.Contains(timeoutErr.Detail(), "timeouts { create = \"30m\" }") ||
+		!strings.Contains(timeoutErr.Detail(), "Timed Out Waiting for Billing Account Switch: Project p still shows") {
+		t.Errorf("unexpected timeout error %q: %s", timeoutErr.Summary(), timeoutErr.Detail())
+	}
+}
@@ -0,0 +1,120 @@
+package burwoodportal
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// Relative expirations count from the budget's issue date: "+90d" for days,
+// and w, m and y for weeks, months and years.
+var relativeExpirationPattern = regexp.MustCompile(`^\+([0-9]+)([dwmy])$`)
+
+// endOfFiscalYear expires a budget on the last day of the fiscal year it was
+// issued in. The fiscal year starts in the provider's fiscal_year_start month.
+const endOfFiscalYear = "end_of_fiscal_year"
+
+// resolveExpiration returns the date an expirationdate value stands for:
+// a date, a relative expiration or end_of_fiscal_year.
+func resolveExpiration(value string, issued time.Time, fiscalYearStart time.Month) (Date, error) {
+	day := time.Date(issued.Year(), issued.Month(), issued.Day(), 0, 0, 0, 0, time.UTC)
+	if fiscalYearStart == 0 {
+		fiscalYearStart = time.January
+	}
+
+	if value == endOfFiscalYear {
+		start := time.Date(day.Year(), fiscalYearStart, 1, 0, 0, 0, 0, time.UTC)
+		if !start.After(day) {
+			start = start.AddDate(1, 0, 0)
+		}
+
+		return Date{Time: start.AddDate(0, 0, -1)}, nil
+	}
+
+	if match := relativeExpirationPattern.FindStringSubmatch(value); match != nil {
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			return Date{}, fmt.Errorf("invalid expiration %q: %w", value, err)
+		}
+
+		switch match[2] {
+		case "d":
+			return Date{Time: day.AddDate(0, 0, n)}, nil
+		case "w":
+			return Date{Time: day.AddDate(0, 0, 7*n)}, nil
+		case "m":
+			return Date{Time: addMonths(day, n)}, nil
+		default:
+			return Date{Time: addMonths(day, 12*n)}, nil
+		}
+	}
+
+	date, err := ParseDate(value)
+	if err != nil {
+		return Date{}, fmt.Errorf("invalid expiration %q, expected a date such as 2006-01-02, a relative expiration such as +90d, or %s", value, endOfFiscalYear)
+	}
+
+	return date, nil
+}
+
+// addMonths adds n months to day, clamped to the last day of the target month:
+// a month after January 31 is February 28, where AddDate overflows into March.
+func addMonths(day time.Time, n int) time.Time {
+	first := time.Date(day.Year(), day.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if day.Day() < lastDay {
+		lastDay = day.Day()
+	}
+
+	return time.Date(first.Year(), first.Month(), lastDay, 0, 0, 0, 0, time.UTC)
+}
+
+// sameExpiration reports whether two expirationdate values stand for the
+// same date, relative ones counting from the budget's issue date.
+func sameExpiration(a, b string, issued string, fiscalYearStart time.Month) bool {
+	if a == b {
+		return true
+	}
+
+	issuedDate, err := ParseDate(issued)
+	if err != nil || (issuedDate.IsZero() && (isRelativeExpiration(a) || isRelativeExpiration(b))) {
+		return false
+	}
+
+	resolvedA, errA := resolveExpiration(a, issuedDate.Time, fiscalYearStart)
+	resolvedB, errB := resolveExpiration(b, issuedDate.Time, fiscalYearStart)
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	return resolvedA.String() == resolvedB.String()
+}
+
+func isRelativeExpiration(value string) bool {
+	return value == endOfFiscalYear || relativeExpirationPattern.MatchString(value)
+}
+
+// expirationValidator - Plan time validation of expirationdate.
+type expirationValidator struct{}
+
+func (v expirationValidator) Description(ctx context.Context) string {
+	return fmt.Sprintf("value must be a date, a relative expiration such as +90d, or %s", endOfFiscalYear)
+}
+
+func (v expirationValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v expirationValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+
+	if _, err := resolveExpiration(req.ConfigValue.ValueString(), time.Now(), time.January); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Expiration Date", err.Error())
+	}
+}
@@ -0,0 +1,108 @@
+package burwoodportal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveExpiration(t *testing.T) {
+	issued := time.Date(2023, time.January, 17, 15, 4, 5, 0, time.UTC)
+
+	cases := map[string]struct {
+		value           string
+		fiscalYearStart time.Month
+		want            string
+		wantErr         bool
+	}{
+		"date":                 {value: "2023-06-30", want: "2023-06-30"},
+		"other format":         {value: "06/30/2023", want: "2023-06-30"},
+		"days":                 {value: "+90d", want: "2023-04-17"},
+		"weeks":                {value: "+2w", want: "2023-01-31"},
+		"months":               {value: "+6m", want: "2023-07-17"},
+		"years":                {value: "+1y", want: "2024-01-17"},
+		"calendar fiscal year": {value: "end_of_fiscal_year", fiscalYearStart: time.January, want: "2023-12-31"},
+		"default fiscal year":  {value: "end_of_fiscal_year", want: "2023-12-31"},
+		"july fiscal year":     {value: "end_of_fiscal_year", fiscalYearStart: time.July, want: "2023-06-30"},
+		"empty":                {value: "", want: ""},
+		"negative":             {value: "-90d", wantErr: true},
+		"unknown unit":         {value: "+90h", wantErr: true},
+		"not a date":           {value: "soon", wantErr: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := resolveExpiration(tc.value, issued, tc.fiscalYearStart)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tc.want {
+				t.Errorf("resolved %q to %s, want %s", tc.value, got, tc.want)
+			}
+		})
+	}
+
+	// Months and years end on the last day of a shorter target month.
+	clamped := map[string]struct{ issued, value, want string }{
+		"end of january":      {issued: "2023-01-31", value: "+1m", want: "2023-02-28"},
+		"leap year february":  {issued: "2024-01-31", value: "+1m", want: "2024-02-29"},
+		"thirty day month":    {issued: "2023-03-31", value: "+1m", want: "2023-04-30"},
+		"into the next year":  {issued: "2023-08-31", value: "+6m", want: "2024-02-29"},
+		"leap day":            {issued: "2024-02-29", value: "+1y", want: "2025-02-28"},
+		"short month to long": {issued: "2023-02-28", value: "+1m", want: "2023-03-28"},
+	}
+	for name, tc := range clamped {
+		issuedDate, err := ParseDate(tc.issued)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := resolveExpiration(tc.value, issuedDate.Time, time.January)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != tc.want {
+			t.Errorf("%s: resolved %q from %s to %s, want %s", name, tc.value, tc.issued, got, tc.want)
+		}
+	}
+
+	// A fiscal year starting on the issue date ends a year later.
+	got, err := resolveExpiration(endOfFiscalYear, time.Date(2023, time.July, 1, 0, 0, 0, 0, time.UTC), time.July)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != "2024-06-30" {
+		t.Errorf("resolved end_of_fiscal_year on its first day to %s, want 2024-06-30", got)
+	}
+}
+
+func TestSameExpiration(t *testing.T) {
+	cases := map[string]struct {
+		a, b   string
+		issued string
+		want   bool
+	}{
+		"identical":              {a: "+90d", b: "+90d", want: true},
+		"formats":                {a: "2023-06-30", b: "2023-06-30T00:00:00Z", want: true},
+		"different dates":        {a: "2023-06-30", b: "2023-07-01", want: false},
+		"relative":               {a: "+90d", b: "2023-04-17", issued: "2023-01-17", want: true},
+		"relative other format":  {a: "+90d", b: "Mon, 17 Apr 2023 00:00:00 GMT", issued: "2023-01-17", want: true},
+		"relative other date":    {a: "+90d", b: "2023-04-18", issued: "2023-01-17", want: false},
+		"relative without issue": {a: "+90d", b: "2023-04-17", want: false},
+		"fiscal year":            {a: "end_of_fiscal_year", b: "2023-12-31", issued: "2023-01-17", want: true},
+		"unset":                  {a: "", b: "2023-06-30", want: false},
+		"invalid":                {a: "soon", b: "2023-06-30", want: false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := sameExpiration(tc.a, tc.b, tc.issued, time.January); got != tc.want {
+				t.Errorf("sameExpiration(%q, %q, %q) = %t, want %t", tc.a, tc.b, tc.issued, got, tc.want)
+			}
+		})
+	}
+}
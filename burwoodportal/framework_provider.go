@@ -0,0 +1,191 @@
+package burwoodportal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	providerschema "github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-mux/tf5muxserver"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// ProviderServer - Protocol 5 server muxing the plugin framework provider with
+// the plugin SDK provider. Resources move to the framework one at a time,
+// upgrading the SDK's state where their schema changes.
+//
+// Only the framework half configures a client. The SDK half serves no
+// resources and only validates the provider block, so it isn't configured
+// and doesn't sign in a second time or repeat configuration errors.
+func ProviderServer(ctx context.Context, version string) (func() tfprotov5.ProviderServer, error) {
+	muxServer, err := tf5muxserver.NewMuxServer(ctx,
+		providerserver.NewProtocol5(NewFrameworkProvider(version)()),
+		providerSchema().GRPCProvider,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return muxServer.ProviderServer, nil
+}
+
+// frameworkProvider - Plugin framework half of the provider.
+// Its schema must match the plugin SDK provider's, see providerSchema.
+type frameworkProvider struct {
+	version string
+}
+
+// NewFrameworkProvider - Plugin framework provider factory.
+func NewFrameworkProvider(version string) func() provider.Provider {
+	return func() provider.Provider {
+		return &frameworkProvider{version: version}
+	}
+}
+
+// frameworkProviderModel - Provider block, see providerSchema.
+type frameworkProviderModel struct {
+	Host               types.String          `tfsdk:"host"`
+	Profile            types.String          `tfsdk:"profile"`
+	CredentialsFile    types.String          `tfsdk:"credentials_file"`
+	StrictDecoding     types.Bool            `tfsdk:"strict_decoding"`
+	FiscalYearStart    types.String          `tfsdk:"fiscal_year_start"`
+	RequestTimeout     types.String          `tfsdk:"request_timeout"`
+	ProxyURL           types.String          `tfsdk:"proxy_url"`
+	CABundle           types.String          `tfsdk:"ca_bundle"`
+	ClientCertificate  types.String          `tfsdk:"client_certificate"`
+	ClientKey          types.String          `tfsdk:"client_key"`
+	MinTLSVersion      types.String          `tfsdk:"min_tls_version"`
+	InsecureSkipVerify types.Bool            `tfsdk:"insecure_skip_verify"`
+	Username           types.String          `tfsdk:"username"`
+	Password           types.String          `tfsdk:"password"`
+	Token              types.String          `tfsdk:"token"`
+	OAuth              []frameworkOAuthModel `tfsdk:"oauth"`
+}
+
+type frameworkOAuthModel struct {
+	TokenURL     types.String   `tfsdk:"token_url"`
+	ClientID     types.String   `tfsdk:"client_id"`
+	ClientSecret types.String   `tfsdk:"client_secret"`
+	Scopes       []types.String `tfsdk:"scopes"`
+}
+
+func (p *frameworkProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
+	resp.TypeName = "burwoodportal"
+	resp.Version = p.version
+}
+
+// Schema mirrors the plugin SDK provider schema, reusing its descriptions
+// so the muxed schemas stay identical. Validation is left to the SDK provider.
+func (p *frameworkProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
+	sdkSchema := providerSchema().Schema
+	sdkOAuthSchema := oauthSchema.Schema
+
+	attributes := map[string]providerschema.Attribute{}
+	for name, s := range sdkSchema {
+		switch name {
+		case "oauth":
+			continue
+		case "strict_decoding", "insecure_skip_verify":
+			attributes[name] = providerschema.BoolAttribute{Optional: true, Description: s.Description}
+		default:
+			attributes[name] = providerschema.StringAttribute{Optional: true, Sensitive: s.Sensitive, Description: s.Description}
+		}
+	}
+
+	resp.Schema = providerschema.Schema{
+		Attributes: attributes,
+		Blocks: map[string]providerschema.Block{
+			"oauth": providerschema.ListNestedBlock{
+				Description: sdkSchema["oauth"].Description,
+				NestedObject: providerschema.NestedBlockObject{
+					Attributes: map[string]providerschema.Attribute{
+						"token_url":     providerschema.StringAttribute{Required: true, Description: sdkOAuthSchema["token_url"].Description},
+						"client_id":     providerschema.StringAttribute{Required: true, Description: sdkOAuthSchema["client_id"].Description},
+						"client_secret": providerschema.StringAttribute{Required: true, Sensitive: true, Description: sdkOAuthSchema["client_secret"].Description},
+						"scopes":        providerschema.ListAttribute{Optional: true, ElementType: types.StringType, Description: sdkOAuthSchema["scopes"].Description},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (p *frameworkProvider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
+	var model frameworkProviderModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &model)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	config := providerConfig{
+		Host:               model.Host.ValueString(),
+		Profile:            model.Profile.ValueString(),
+		CredentialsFile:    model.CredentialsFile.ValueString(),
+		FiscalYearStart:    model.FiscalYearStart.ValueString(),
+		RequestTimeout:     model.RequestTimeout.ValueString(),
+		ProxyURL:           model.ProxyURL.ValueString(),
+		CABundle:           model.CABundle.ValueString(),
+		ClientCertificate:  model.ClientCertificate.ValueString(),
+		ClientKey:          model.ClientKey.ValueString(),
+		MinTLSVersion:      model.MinTLSVersion.ValueString(),
+		InsecureSkipVerify: model.InsecureSkipVerify.ValueBool(),
+		Username:           model.Username.ValueString(),
+		Password:           model.Password.ValueString(),
+		Token:              model.Token.ValueString(),
+	}
+	if !model.StrictDecoding.IsNull() {
+		strictDecoding := model.StrictDecoding.ValueBool()
+		config.StrictDecoding = &strictDecoding
+	}
+	if len(model.OAuth) > 0 {
+		scopes := []string{}
+		for _, scope := range model.OAuth[0].Scopes {
+			scopes = append(scopes, scope.ValueString())
+		}
+		config.OAuth = &clientcredentials.Config{
+			TokenURL:     model.OAuth[0].TokenURL.ValueString(),
+			ClientID:     model.OAuth[0].ClientID.ValueString(),
+			ClientSecret: model.OAuth[0].ClientSecret.ValueString(),
+			Scopes:       scopes,
+		}
+	}
+
+	c, diags := configureClient(ctx, config, frameworkUserAgent(req.TerraformVersion, p.version))
+	resp.Diagnostics.Append(frameworkDiagnostics(diags)...)
+	if c == nil {
+		return
+	}
+
+	resp.DataSourceData = c
+	resp.ResourceData = c
+}
+
+func (p *frameworkProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
+	return []func() datasource.DataSource{
+		NewHierarchyDataSource,
+	}
+}
+
+func (p *frameworkProvider) Resources(ctx context.Context) []func() resource.Resource {
+	return []func() resource.Resource{
+		NewProjectResource,
+	}
+}
+
+// frameworkUserAgent follows the plugin SDK's User-Agent format, including
+// its TF_APPEND_USER_AGENT environment variable.
+func frameworkUserAgent(terraformVersion, version string) string {
+	userAgent := fmt.Sprintf("Terraform/%s (+https://www.terraform.io) Terraform-Plugin-Framework terraform-provider-burwoodportal/%s", terraformVersion, version)
+	if add := strings.TrimSpace(os.Getenv("TF_APPEND_USER_AGENT")); add != "" {
+		userAgent += " " + add
+	}
+
+	return userAgent
+}
@@ -0,0 +1,4 @@
+package burwoodportal
+
+// The typed API methods in api_gen.go are generated from the vendored OpenAPI spec.
+//go:generate go run ../tools/gen-portalapi -spec ../api/openapi.json -out api_gen.go
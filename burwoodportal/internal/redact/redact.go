@@ -0,0 +1,76 @@
+// Package redact masks credentials in portal API traffic. The provider's
+// logs and the test cassettes both use it, so they hide the same secrets.
+package redact
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Mask replaces credential values.
+const Mask = "[REDACTED]"
+
+// Headers whose values are credentials.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":  true,
+	"X-Access-Token": true,
+}
+
+// JSON fields whose values are credentials, compared case-insensitively.
+var sensitiveFields = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"client_secret": true,
+}
+
+// Headers flattens headers, masking credentials.
+func Headers(header http.Header) map[string]string {
+	redacted := make(map[string]string, len(header))
+	for name, values := range header {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			redacted[name] = Mask
+			continue
+		}
+		redacted[name] = strings.Join(values, ", ")
+	}
+
+	return redacted
+}
+
+// JSON masks credential fields in a JSON body.
+// Bodies that are not JSON are returned as-is.
+func JSON(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+
+	redacted, err := json.Marshal(value(v))
+	if err != nil {
+		return string(body)
+	}
+
+	return string(redacted)
+}
+
+func value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if sensitiveFields[strings.ToLower(k)] {
+				v[k] = Mask
+				continue
+			}
+			v[k] = value(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = value(item)
+		}
+	}
+
+	return v
+}
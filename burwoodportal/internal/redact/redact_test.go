@@ -0,0 +1,44 @@
+package redact
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret")
+	header.Set("x-access-token", "secret")
+	header.Add("Accept", "application/json")
+	header.Add("Accept", "text/plain")
+
+	want := map[string]string{
+		"Authorization":  Mask,
+		"X-Access-Token": Mask,
+		"Accept":         "application/json, text/plain",
+	}
+	if got := Headers(header); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	cases := map[string]struct {
+		body string
+		want string
+	}{
+		"sign in":     {`{"username":"me","Password":"secret"}`, `{"Password":"[REDACTED]","username":"me"}`},
+		"oauth token": {`{"access_token":"secret","expires_in":3600}`, `{"access_token":"[REDACTED]","expires_in":3600}`},
+		"nested":      {`[{"auth":{"token":"secret"}}]`, `[{"auth":{"token":"[REDACTED]"}}]`},
+		"not JSON":    {`client_secret=secret`, `client_secret=secret`},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := JSON([]byte(tc.body)); got != tc.want {
+				t.Errorf("got %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,16 @@
 package burwoodportal
 
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// Models mirror the component schemas of the same name in api/openapi.json.
+// TestModelsMatchSpec keeps the two in sync.
+
 type Group struct {
 	GroupName   string `json:"groupname"`
 	GroupID     string  `json:"groupid"`
@@ -9,12 +20,13 @@ type Group struct {
 type Department struct {
 	DepartmentName string `json:"departmentname"`
 	DepartmentID   string  `json:"departmentid"`
-	Projects    []Project `json:"projects"`
+	Projects    []HierarchyProject `json:"projects"`
 }
 
-// type Project struct {
-// 	ProjectID string `json:"projectid"`
-// }
+type HierarchyProject struct {
+	ProjectID   string `json:"projectid"`
+	ProjectName string `json:"projectname"`
+}
 
 type Project struct {
 	ProjectID 				string  `json:"projectid"`
@@ -25,7 +37,8 @@ type Project struct {
 	AfterCreditsAccount		string  `json:"aftercreditsaccount"`
 	AfterCreditsPO			string	`json:"aftercreditspo"`
 	PaidBillingAccount  	string 	`json:"paidbillingaccount"`
-	TotalBudget				string	`json:"totalbudget"`
+	// Read-only, computed by the portal. Nil in requests, so it isn't sent.
+	TotalBudget				*Money	`json:"totalbudget,omitempty"`
 	RecurringBudget  		bool  	`json:"recurringbudget"`
 	DepartmentID  			string  `json:"departmentid"`
 	DepartmentName  		string  `json:"departmentname"`
@@ -34,29 +47,228 @@ type Project struct {
 type Allowance struct {
 	PONumber       			string	 `json:"ponumber"`	
 	Grant					string 	 `json:"grant"`
-	Amount					float64	 `json:"amount"`
+	Amount					Money	 `json:"amount"`
 	BillingAccountID		string	 `json:"billingaccountid"`
-	ExpirationDate			string	 `json:"expirationdate"`
-	DateSuspended			string   `json:"datesuspended"`
-	DateActivated			string   `json:"dateactivated"`
-	DateIssued				string	 `json:"dateissued"`
+	ExpirationDate			Date	 `json:"expirationdate"`
+	DateSuspended			Date     `json:"datesuspended"`
+	DateActivated			Date     `json:"dateactivated"`
+	DateIssued				Date	 `json:"dateissued"`
 	State					string   `json:"state"`
 	Recurring				bool  `json:"recurring"`
-	ActualSpend				float64 `json:"actualspend"`
+	// Read-only, tracked by the portal. Nil in requests, so it isn't sent.
+	ActualSpend				*Money `json:"actualspend,omitempty"`
+	Currency				string `json:"currency,omitempty"`
 
 }
 
 type ReportingProject struct {
-	ProjectID       string  `json:"project_id"`
-	CostTotal       float64 `json:"cost_total"`
-	StrideDiscount  string  `json:"stride_discount"`
-	I2Discount      string  `json:"i2_discount"`
-	ContractCost    string  `json:"contract_cost"`
-	DiscountTotal   string  `json:"discount_total"`
-	Consumption     string  `json:"consumption"`
-	GcpInvoiceCost  string  `json:"gcp_invoice_cost"`
-	GeneralDiscount string  `json:"general_discount"`
-	Markup          string  `json:"markup"`
-	Adjustments     string  `json:"adjustments"`
-	Subtotal        string  `json:"subtotal"`
+	ProjectID       string `json:"project_id"`
+	CostTotal       Money  `json:"cost_total"`
+	StrideDiscount  Money  `json:"stride_discount"`
+	I2Discount      Money  `json:"i2_discount"`
+	ContractCost    Money  `json:"contract_cost"`
+	DiscountTotal   Money  `json:"discount_total"`
+	Consumption     Money  `json:"consumption"`
+	GcpInvoiceCost  Money  `json:"gcp_invoice_cost"`
+	GeneralDiscount Money  `json:"general_discount"`
+	Markup          Money  `json:"markup"`
+	Adjustments     Money  `json:"adjustments"`
+	Subtotal        Money  `json:"subtotal"`
+	Currency        string `json:"currency,omitempty"`
+}
+
+// Money - Exact decimal amount of money. Depending on the endpoint the portal
+// sends amounts as JSON numbers or as strings; both decode, and amounts always
+// encode as numbers. Amounts are kept in their shortest decimal form, so
+// "1337.00" and 1337 are the same amount. The zero value is no amount.
+type Money struct {
+	decimal string
+}
+
+// ParseMoney parses a decimal amount such as "1337", "-12.5" or "1.5e3".
+// An empty string is no amount.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Money{}, nil
+	}
+
+	// Only the JSON number grammar, not the fractions and base prefixes big.Rat accepts.
+	if (s[0] != '-' && (s[0] < '0' || s[0] > '9')) || !json.Valid([]byte(s)) {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+
+	return Money{decimal: r.FloatString(decimalPlaces(r))}, nil
+}
+
+// decimalPlaces returns the number of fractional digits r needs. Amounts are
+// parsed from decimals, so the denominator only has the factors 2 and 5.
+func decimalPlaces(r *big.Rat) int {
+	denominator := new(big.Int).Set(r.Denom())
+	ten, five, two := big.NewInt(10), big.NewInt(5), big.NewInt(2)
+	zero, remainder := new(big.Int), new(big.Int)
+
+	places := 0
+	for denominator.Cmp(big.NewInt(1)) != 0 {
+		switch {
+		case remainder.Mod(denominator, ten).Cmp(zero) == 0:
+			denominator.Quo(denominator, ten)
+		case remainder.Mod(denominator, two).Cmp(zero) == 0:
+			denominator.Quo(denominator, two)
+		default:
+			denominator.Quo(denominator, five)
+		}
+		places++
+	}
+
+	return places
+}
+
+// MoneyFromBigFloat converts a Terraform number, which is a big.Float, to an amount.
+func MoneyFromBigFloat(f *big.Float) (Money, error) {
+	if f == nil {
+		return Money{}, nil
+	}
+	if f.IsInf() {
+		return Money{}, fmt.Errorf("invalid amount %s", f.String())
+	}
+
+	return ParseMoney(f.Text('f', -1))
+}
+
+// IsNull reports whether m is no amount.
+func (m Money) IsNull() bool {
+	return m.decimal == ""
+}
+
+// BigFloat converts m to a Terraform number, nil if m is no amount.
+func (m Money) BigFloat() *big.Float {
+	if m.IsNull() {
+		return nil
+	}
+
+	// Terraform's own precision, so equal amounts compare equal in plans.
+	f, _, _ := big.ParseFloat(m.decimal, 10, 512, big.ToNearestEven)
+
+	return f
+}
+
+func (m Money) String() string {
+	return m.decimal
+}
+
+// MarshalJSON encodes m as a JSON number. No amount encodes as null.
+func (m Money) MarshalJSON() ([]byte, error) {
+	if m.IsNull() {
+		return []byte("null"), nil
+	}
+
+	return []byte(m.decimal), nil
+}
+
+// UnmarshalJSON decodes a JSON number, a string holding one, or null.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		*m = Money{}
+		return nil
+	}
+
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+
+	return nil
+}
+
+// Date - Budget date. The portal returns dates in varying formats, see
+// dateLayouts. Dates without a time of day format as YYYY-MM-DD, others as
+// RFC 3339 in UTC, so equivalent representations read the same. The zero
+// value is no date.
+type Date struct {
+	time.Time
+}
+
+// Layouts the portal has been seen to use. Times without a zone are UTC.
+var dateLayouts = []string{
+	"2006-01-02",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"Mon, 02 Jan 2006 15:04:05 GMT",
+	time.RFC1123Z,
+	"01/02/2006",
+}
+
+// ParseDate parses a date in any of dateLayouts. An empty string is no date.
+func ParseDate(s string) (Date, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Date{}, nil
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return Date{Time: t}, nil
+		}
+	}
+
+	return Date{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD or RFC 3339", s)
+}
+
+// IsDateOnly reports whether d has no time of day.
+func (d Date) IsDateOnly() bool {
+	return d.Hour() == 0 && d.Minute() == 0 && d.Second() == 0 && d.Nanosecond() == 0
+}
+
+func (d Date) String() string {
+	switch {
+	case d.IsZero():
+		return ""
+	case d.IsDateOnly():
+		return d.Format("2006-01-02")
+	default:
+		return d.UTC().Format(time.RFC3339)
+	}
+}
+
+// MarshalJSON encodes d in its normalized form. No date encodes as "".
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON decodes a date string, "" or null.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = Date{}
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid date %s: %w", data, err)
+	}
+
+	parsed, err := ParseDate(s)
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+
+	return nil
 }
@@ -0,0 +1,289 @@
+package burwoodportal
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Go model for each component schema in api/openapi.json.
+var specModels = map[string]reflect.Type{
+	"AuthResponse":     reflect.TypeOf(AuthResponse{}),
+	"Group":            reflect.TypeOf(Group{}),
+	"Department":       reflect.TypeOf(Department{}),
+	"HierarchyProject": reflect.TypeOf(HierarchyProject{}),
+	"Project":          reflect.TypeOf(Project{}),
+	"Allowance":        reflect.TypeOf(Allowance{}),
+	"ReportingProject": reflect.TypeOf(ReportingProject{}),
+}
+
+type specSchema struct {
+	Ref        string                 `json:"$ref"`
+	Type       string                 `json:"type"`
+	Items      *specSchema            `json:"items"`
+	Properties map[string]*specSchema `json:"properties"`
+}
+
+func TestModelsMatchSpec(t *testing.T) {
+	raw, err := ioutil.ReadFile("../api/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var spec struct {
+		Components struct {
+			Schemas map[string]*specSchema `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		t.Fatal(err)
+	}
+
+	for name := range specModels {
+		if _, ok := spec.Components.Schemas[name]; !ok {
+			t.Errorf("model %s has no schema in the spec", name)
+		}
+	}
+
+	for name, schema := range spec.Components.Schemas {
+		model, ok := specModels[name]
+		if !ok {
+			t.Errorf("schema %s has no Go model", name)
+			continue
+		}
+
+		fields := map[string]reflect.StructField{}
+		for i := 0; i < model.NumField(); i++ {
+			field := model.Field(i)
+			if jsonName, _ := jsonFieldName(field); jsonName != "" {
+				fields[jsonName] = field
+			}
+		}
+
+		for property, propertySchema := range schema.Properties {
+			field, ok := fields[property]
+			if !ok {
+				t.Errorf("%s.%s is in the spec but not in the model", name, property)
+				continue
+			}
+			if err := checkSpecType(propertySchema, field.Type); err != "" {
+				t.Errorf("%s.%s: %s", name, property, err)
+			}
+		}
+
+		for jsonName := range fields {
+			if _, ok := schema.Properties[jsonName]; !ok {
+				t.Errorf("%s.%s is in the model but not in the spec", name, jsonName)
+			}
+		}
+	}
+}
+
+// checkSpecType returns a description of the mismatch between a property
+// schema and the Go type of its field, or "" if they agree.
+func checkSpecType(s *specSchema, goType reflect.Type) string {
+	for goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		if specModels[name] != goType {
+			return "expected model " + name + ", got " + goType.String()
+		}
+		return ""
+	}
+
+	ok := false
+	switch s.Type {
+	case "string":
+		ok = (goType.Kind() == reflect.String && goType != reflect.TypeOf(json.Number(""))) || goType == reflect.TypeOf(Date{})
+	case "number":
+		ok = goType == reflect.TypeOf(json.Number("")) || goType == reflect.TypeOf(Money{}) || goType.Kind() == reflect.Float64
+	case "integer":
+		ok = goType.Kind() == reflect.Int || goType.Kind() == reflect.Int64
+	case "boolean":
+		ok = goType.Kind() == reflect.Bool
+	case "array":
+		if goType.Kind() != reflect.Slice {
+			return "expected a slice, got " + goType.String()
+		}
+		return checkSpecType(s.Items, goType.Elem())
+	}
+
+	if !ok {
+		return "spec type " + s.Type + " does not match " + goType.String()
+	}
+	return ""
+}
+
+func TestMoneyJSON(t *testing.T) {
+	cases := map[string]struct {
+		json    string
+		want    string
+		wantErr bool
+	}{
+		"integer":         {json: `1337`, want: "1337"},
+		"decimal":         {json: `1337.5`, want: "1337.5"},
+		"trailing zeros":  {json: `1337.00`, want: "1337"},
+		"exponent":        {json: `1.5e3`, want: "1500"},
+		"negative":        {json: `-0.25`, want: "-0.25"},
+		"cents":           {json: `0.1`, want: "0.1"},
+		"string":          {json: `"1336.9999"`, want: "1336.9999"},
+		"padded string":   {json: `" 100.10 "`, want: "100.1"},
+		"empty string":    {json: `""`, want: ""},
+		"null":            {json: `null`, want: ""},
+		"not a number":    {json: `"lots"`, wantErr: true},
+		"fraction":        {json: `"1/3"`, wantErr: true},
+		"hex":             {json: `"0x10"`, wantErr: true},
+		"quoted twice":    {json: `"\"1\""`, wantErr: true},
+		"boolean":         {json: `true`, wantErr: true},
+		"unexpected type": {json: `{}`, wantErr: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var m Money
+			err := json.Unmarshal([]byte(tc.json), &m)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", m)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.String() != tc.want {
+				t.Errorf("decoded %s as %q, want %q", tc.json, m, tc.want)
+			}
+		})
+	}
+}
+
+func TestMoneyMarshalJSON(t *testing.T) {
+	encoded, err := json.Marshal(Allowance{Amount: testMoney("1337.10")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), `"amount":1337.1,`) {
+		t.Errorf("amount not encoded as a number: %s", encoded)
+	}
+
+	encoded, err = json.Marshal(Allowance{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), `"amount":null,`) {
+		t.Errorf("missing amount not encoded as null: %s", encoded)
+	}
+
+	// Read-only amounts are left out of requests.
+	encoded, err = json.Marshal(Project{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(encoded), "totalbudget") {
+		t.Errorf("read-only totalbudget sent: %s", encoded)
+	}
+}
+
+func TestMoneyBigFloat(t *testing.T) {
+	for _, s := range []string{"1337", "1337.1", "0.07", "-12.5", "99999999999.99"} {
+		m := testMoney(s)
+
+		// Terraform parses configuration numbers at the same precision.
+		config, _, err := big.ParseFloat(s, 10, 512, big.ToNearestEven)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.BigFloat().Cmp(config) != 0 {
+			t.Errorf("%s: BigFloat() = %s, want %s", s, m.BigFloat().Text('f', -1), config.Text('f', -1))
+		}
+
+		roundTrip, err := MoneyFromBigFloat(m.BigFloat())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if roundTrip != m {
+			t.Errorf("%s: round trip gave %s", s, roundTrip)
+		}
+	}
+
+	if testMoney("").BigFloat() != nil {
+		t.Error("no amount converted to a number")
+	}
+	if _, err := MoneyFromBigFloat(new(big.Float).SetInf(false)); err == nil {
+		t.Error("infinity converted to an amount")
+	}
+}
+
+func testMoney(s string) Money {
+	m, err := ParseMoney(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}
+
+func TestDateJSON(t *testing.T) {
+	cases := map[string]struct {
+		json    string
+		want    string
+		wantErr bool
+	}{
+		"date":               {json: `"2023-01-17"`, want: "2023-01-17"},
+		"midnight UTC":       {json: `"2023-01-17T00:00:00Z"`, want: "2023-01-17"},
+		"midnight offset":    {json: `"2023-01-17T00:00:00-05:00"`, want: "2023-01-17"},
+		"time of day":        {json: `"2023-01-17T10:30:00Z"`, want: "2023-01-17T10:30:00Z"},
+		"time of day offset": {json: `"2023-01-17T22:30:00-05:00"`, want: "2023-01-18T03:30:00Z"},
+		"fractional seconds": {json: `"2023-01-17T10:30:00.123Z"`, want: "2023-01-17T10:30:00Z"},
+		"no zone":            {json: `"2023-01-17T10:30:00"`, want: "2023-01-17T10:30:00Z"},
+		"space separated":    {json: `"2023-01-17 10:30:00"`, want: "2023-01-17T10:30:00Z"},
+		"http date":          {json: `"Tue, 17 Jan 2023 00:00:00 GMT"`, want: "2023-01-17"},
+		"us date":            {json: `"01/17/2023"`, want: "2023-01-17"},
+		"empty":              {json: `""`, want: ""},
+		"null":               {json: `null`, want: ""},
+		"not a date":         {json: `"soon"`, wantErr: true},
+		"invalid date":       {json: `"2023-02-30"`, wantErr: true},
+		"number":             {json: `20230117`, wantErr: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			var d Date
+			err := json.Unmarshal([]byte(tc.json), &d)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", d)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d.String() != tc.want {
+				t.Errorf("decoded %s as %q, want %q", tc.json, d, tc.want)
+			}
+		})
+	}
+}
+
+func TestDateMarshalJSON(t *testing.T) {
+	date, err := ParseDate("Tue, 17 Jan 2023 00:00:00 GMT")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := json.Marshal(Allowance{ExpirationDate: date})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), `"expirationdate":"2023-01-17"`) || !strings.Contains(string(encoded), `"dateissued":""`) {
+		t.Errorf("dates not normalized: %s", encoded)
+	}
+}
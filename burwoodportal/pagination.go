@@ -0,0 +1,163 @@
+package burwoodportal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxListPages stops runaway pagination, e.g. a server repeating the same next link.
+const maxListPages = 10000
+
+// listPage - Paginated list response envelope.
+//
+// List endpoints either return a bare JSON array, which is a single page,
+// or wrap the items in an object that says where the next page is:
+//   - next: URL or path of the next page (next-link pagination)
+//   - next_cursor: opaque cursor, sent back as the "cursor" query parameter
+//   - page/total_pages/limit: page-number pagination, the next page is
+//     requested with the "page" and "limit" query parameters
+//
+// A Link header with rel="next" is followed for either form.
+type listPage struct {
+	Items      []json.RawMessage `json:"items"`
+	Data       []json.RawMessage `json:"data"`
+	Next       string            `json:"next"`
+	NextCursor string            `json:"next_cursor"`
+	Page       int               `json:"page"`
+	Limit      int               `json:"limit"`
+	TotalPages int               `json:"total_pages"`
+}
+
+// forEachListItem GETs a list endpoint and calls fn with each item as it is
+// read, following pagination transparently. Iteration stops at the first
+// error, from the API or from fn.
+func forEachListItem[T any](ctx context.Context, c *Client, segments []string, fn func(item T) error, options ...requestOption) error {
+	o := newRequestOptions(options)
+	pageURL := c.endpointURL(segments...)
+	seen := map[string]bool{}
+
+	for pages := 0; pageURL != ""; pages++ {
+		if pages >= maxListPages || seen[pageURL] {
+			return fmt.Errorf("pagination of %s did not terminate after %d pages", c.endpointURL(segments...), pages)
+		}
+		seen[pageURL] = true
+
+		req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+		if err != nil {
+			return err
+		}
+		for key, values := range o.header {
+			req.Header[key] = values
+		}
+
+		body, header, err := c.doRequestWithHeader(req, nil)
+		if err != nil {
+			return err
+		}
+
+		items, next, err := parseListPage(req.URL, body, header)
+		if err != nil {
+			return err
+		}
+
+		for _, rawItem := range items {
+			var item T
+			if err := decodeJSON(rawItem, &item); err != nil {
+				return fmt.Errorf("unable to decode %s list item: %w", req.URL.Path, err)
+			}
+			c.checkContract(ctx, fmt.Sprintf("GET %s", req.URL.Path), rawItem, reflect.TypeOf(item))
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+
+		if next != nil && (next.Scheme != c.baseURL.Scheme || next.Host != c.baseURL.Host) {
+			// Never send credentials to another host.
+			return fmt.Errorf("refusing to follow next page link to %s outside of %s", next.Redacted(), c.HostURL)
+		}
+
+		pageURL = ""
+		if next != nil {
+			pageURL = next.String()
+		}
+	}
+
+	return nil
+}
+
+// parseListPage splits a list response into its items and the URL of the
+// next page, which is nil on the last page.
+func parseListPage(current *url.URL, body []byte, header http.Header) ([]json.RawMessage, *url.URL, error) {
+	var items []json.RawMessage
+	var page listPage
+
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, nil, err
+		}
+		items = page.Items
+		if items == nil {
+			items = page.Data
+		}
+	}
+
+	if link := nextLink(header); link != "" {
+		next, err := current.Parse(link)
+		return items, next, err
+	}
+
+	switch {
+	case page.Next != "":
+		next, err := current.Parse(page.Next)
+		return items, next, err
+	case page.NextCursor != "":
+		next := *current
+		query := next.Query()
+		query.Set("cursor", page.NextCursor)
+		next.RawQuery = query.Encode()
+		return items, &next, nil
+	case page.TotalPages > 0 && page.Page < page.TotalPages && len(items) > 0:
+		next := *current
+		query := next.Query()
+		query.Set("page", strconv.Itoa(page.Page+1))
+		if page.Limit > 0 {
+			query.Set("limit", strconv.Itoa(page.Limit))
+		}
+		next.RawQuery = query.Encode()
+		return items, &next, nil
+	}
+
+	return items, nil, nil
+}
+
+// nextLink returns the target of the rel="next" entry of a Link header.
+func nextLink(header http.Header) string {
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				param = strings.ReplaceAll(strings.TrimSpace(param), `"`, "")
+				if strings.EqualFold(param, "rel=next") {
+					return target[1 : len(target)-1]
+				}
+			}
+		}
+	}
+
+	return ""
+}
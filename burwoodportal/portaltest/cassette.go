@@ -0,0 +1,201 @@
+package portaltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"burwoodportal/burwoodportal/internal/redact"
+)
+
+// CassetteModeEnvVar selects whether tests record cassettes against a live
+// portal ("record") or replay checked-in cassettes offline ("replay", the default).
+const CassetteModeEnvVar = "PORTAL_CASSETTE_MODE"
+
+// CassetteMode - Whether a Recorder records or replays.
+type CassetteMode string
+
+const (
+	ModeReplay CassetteMode = "replay"
+	ModeRecord CassetteMode = "record"
+)
+
+// CassetteModeFromEnv returns the mode selected by CassetteModeEnvVar.
+func CassetteModeFromEnv() (CassetteMode, error) {
+	switch mode := CassetteMode(os.Getenv(CassetteModeEnvVar)); mode {
+	case "", ModeReplay:
+		return ModeReplay, nil
+	case ModeRecord:
+		return ModeRecord, nil
+	default:
+		return "", fmt.Errorf("invalid %s %q, expected %q or %q", CassetteModeEnvVar, mode, ModeRecord, ModeReplay)
+	}
+}
+
+// Cassette - Recorded API interactions, in the order they happened.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction - Recorded request and its response.
+type Interaction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// CassetteRequest - Recorded request. URI is relative to the host, so
+// cassettes replay against any host. Request headers are not recorded.
+type CassetteRequest struct {
+	Method string `json:"method"`
+	URI    string `json:"uri"`
+	Body   string `json:"body,omitempty"`
+}
+
+// CassetteResponse - Recorded response.
+type CassetteResponse struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body,omitempty"`
+}
+
+// Recorder - http.RoundTripper recording interactions to, or replaying them
+// from, a cassette file. Credentials are scrubbed before anything is written.
+type Recorder struct {
+	path      string
+	mode      CassetteMode
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder returns a recorder for the cassette at path. In record mode
+// requests are sent through transport, http.DefaultTransport if nil, and
+// the cassette is written by Stop. In replay mode the cassette is loaded
+// and no request leaves the process.
+func NewRecorder(path string, mode CassetteMode, transport http.RoundTripper) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, transport: transport}
+	if r.transport == nil {
+		r.transport = http.DefaultTransport
+	}
+
+	if mode == ModeReplay {
+		raw, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading cassette: %w (record it with %s=%s)", err, CassetteModeEnvVar, ModeRecord)
+		}
+		if err := json.Unmarshal(raw, &r.cassette); err != nil {
+			return nil, fmt.Errorf("loading cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// RoundTrip records or replays one request.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	request := CassetteRequest{Method: req.Method, URI: req.URL.RequestURI()}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		request.Body = redact.JSON(body)
+	}
+
+	if r.mode == ModeRecord {
+		return r.record(req, request)
+	}
+
+	return r.replay(req, request)
+}
+
+func (r *Recorder) record(req *http.Request, request CassetteRequest) (*http.Response, error) {
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: request,
+		Response: CassetteResponse{
+			StatusCode:  res.StatusCode,
+			ContentType: res.Header.Get("Content-Type"),
+			Body:        redact.JSON(body),
+		},
+	})
+
+	return res, nil
+}
+
+// replay answers with the first unused interaction for the same method and URI.
+func (r *Recorder) replay(req *http.Request, request CassetteRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || interaction.Request.Method != request.Method || interaction.Request.URI != request.URI {
+			continue
+		}
+		r.used[i] = true
+
+		header := http.Header{}
+		if interaction.Response.ContentType != "" {
+			header.Set("Content-Type", interaction.Response.ContentType)
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("cassette %s has no unused interaction for %s %s", r.path, request.Method, request.URI)
+}
+
+// Stop writes the cassette in record mode. It is a no-op in replay mode.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	raw, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(r.path, append(raw, '\n'), 0644)
+}
@@ -0,0 +1,722 @@
+// Package portaltest provides an in-process fake of the Burwood portal REST API
+// for unit and acceptance tests that must not touch the network.
+//
+// The fake keeps projects, budgets and the group hierarchy in memory and
+// implements the endpoints the provider uses:
+//
+//	POST   /token                              Basic auth sign-in
+//	POST   /oauth/token                        OAuth2 client-credentials grant
+//	GET    /api/project/{projectid}
+//	POST   /api/project/{projectid}
+//	DELETE /api/project/{projectid}
+//	GET    /api/project/{projectid}/budgets
+//	POST   /api/{scope}/{entityid}/add_budget
+//	GET    /api/group_hierarchy
+//	POST   /api/group_hierarchy
+//
+// Faults (latency, error statuses, expired tokens) can be injected and every
+// request is recorded for assertions.
+package portaltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default credentials accepted by the fake.
+const (
+	Username     = "test-user"
+	Password     = "test-password"
+	ClientID     = "test-client"
+	ClientSecret = "test-client-secret"
+)
+
+// Department every project without a valid department is placed into,
+// mirroring the portal's 'Unaffiliated Projects'.
+const (
+	UnaffiliatedGroupID      = "unaffiliated"
+	UnaffiliatedDepartmentID = "unaffiliated"
+)
+
+// DefaultCurrency is given to budgets added without a currency.
+const DefaultCurrency = "USD"
+
+// Project - Project as stored and returned by the fake.
+type Project struct {
+	ProjectID           string      `json:"projectid"`
+	ProjectName         string      `json:"projectname"`
+	PrimaryContactEmail string      `json:"primarycontactemail"`
+	BillingContactEmail string      `json:"billingcontactemail"`
+	AfterCredits        string      `json:"aftercredits"`
+	AfterCreditsAccount string      `json:"aftercreditsaccount"`
+	AfterCreditsPO      string      `json:"aftercreditspo"`
+	PaidBillingAccount  string      `json:"paidbillingaccount"`
+	TotalBudget         json.Number `json:"totalbudget"`
+	RecurringBudget     bool        `json:"recurringbudget"`
+	DepartmentID        string      `json:"departmentid"`
+	DepartmentName      string      `json:"departmentname"`
+}
+
+// Budget - Budget (allowance) as stored and returned by the fake.
+type Budget struct {
+	PONumber         string  `json:"ponumber"`
+	Grant            string  `json:"grant"`
+	Amount           float64 `json:"amount"`
+	BillingAccountID string  `json:"billingaccountid"`
+	ExpirationDate   string  `json:"expirationdate"`
+	DateSuspended    string  `json:"datesuspended"`
+	DateActivated    string  `json:"dateactivated"`
+	DateIssued       string  `json:"dateissued"`
+	State            string  `json:"state"`
+	Recurring        bool    `json:"recurring"`
+	ActualSpend      float64 `json:"actualspend"`
+	Currency         string  `json:"currency"`
+}
+
+// Group - Group of departments in the hierarchy.
+type Group struct {
+	GroupName   string       `json:"groupname"`
+	GroupID     string       `json:"groupid"`
+	Departments []Department `json:"departments"`
+}
+
+// Department - Department of projects in the hierarchy.
+type Department struct {
+	DepartmentName string             `json:"departmentname"`
+	DepartmentID   string             `json:"departmentid"`
+	Projects       []HierarchyProject `json:"projects"`
+}
+
+// HierarchyProject - Project reference in the hierarchy.
+type HierarchyProject struct {
+	ProjectID   string `json:"projectid"`
+	ProjectName string `json:"projectname"`
+}
+
+// Fault - Injected failure for requests matching Method and Path.
+type Fault struct {
+	// Method to match, empty for any.
+	Method string
+	// Path prefix to match, empty for any, e.g. "/api/project/".
+	Path string
+	// Latency added before responding.
+	Latency time.Duration
+	// Status to respond with instead of handling the request. Zero only adds Latency.
+	Status int
+	// Body sent with Status.
+	Body string
+	// Times the fault fires before it is removed, zero for every matching request.
+	Times int
+}
+
+// RecordedRequest - Request received by the fake.
+type RecordedRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   string
+}
+
+// Server - Fake portal API. Embeds the running httptest.Server;
+// point the provider's host at URL and Close it when done.
+type Server struct {
+	*httptest.Server
+
+	// PageSize splits list responses into pages of this many items using
+	// page/limit envelopes. Zero returns bare arrays.
+	PageSize int
+	// TokenTTL is the OAuth2 access token lifetime reported to clients.
+	TokenTTL time.Duration
+	// BillingSwitchDelay is how long switching a project's GCP billing account
+	// takes, like the portal does asynchronously. Until then the project keeps
+	// its billing account and an activated budget stays 'Future'.
+	BillingSwitchDelay time.Duration
+
+	mu       sync.Mutex
+	now      func() time.Time
+	tokens   map[string]bool
+	tokenSeq int
+	projects map[string]*Project
+	budgets  map[string][]Budget
+	groups   []Group
+	faults   []*Fault
+	requests []RecordedRequest
+	switches []billingSwitch
+}
+
+// billingSwitch - Pending billing account switch, see BillingSwitchDelay.
+type billingSwitch struct {
+	projectID string
+	account   string
+	// budget is the index of the budget to activate, -1 for none.
+	budget int
+	due    time.Time
+}
+
+// NewServer starts a fake portal with only the unaffiliated department.
+func NewServer() *Server {
+	s := &Server{
+		TokenTTL: time.Hour,
+		now:      time.Now,
+		tokens:   map[string]bool{},
+		projects: map[string]*Project{},
+		budgets:  map[string][]Budget{},
+		groups: []Group{{
+			GroupName: "Unaffiliated",
+			GroupID:   UnaffiliatedGroupID,
+			Departments: []Department{{
+				DepartmentName: "Unaffiliated Projects",
+				DepartmentID:   UnaffiliatedDepartmentID,
+			}},
+		}},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// IssueToken returns a valid API token, e.g. for the provider's token attribute.
+func (s *Server) IssueToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.issueToken()
+}
+
+// ExpireTokens invalidates every issued token, as if they had expired.
+// Subsequent API calls get 401 until the client signs in again.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens = map[string]bool{}
+}
+
+// InjectFault adds a fault. Faults are checked in the order they were added.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all injected faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []RecordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]RecordedRequest{}, s.requests...)
+}
+
+// AddGroup adds a group with its departments to the hierarchy.
+func (s *Server) AddGroup(group Group) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range group.Departments {
+		group.Departments[i].Projects = nil
+	}
+	s.groups = append(s.groups, group)
+}
+
+// PutProject stores a project as-is, bypassing the API, e.g. to simulate
+// changes made in the portal UI.
+func (s *Server) PutProject(project Project) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.saveProject(project)
+}
+
+// Project returns a stored project.
+func (s *Server) Project(projectID string) (Project, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[projectID]
+	if !ok {
+		return Project{}, false
+	}
+
+	return *project, true
+}
+
+// Projects returns the IDs of all stored projects, sorted.
+func (s *Server) Projects() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.projectIDs()
+}
+
+// DeleteProject removes a project and its budgets, bypassing the API,
+// e.g. to simulate an out-of-band delete.
+func (s *Server) DeleteProject(projectID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.projects, projectID)
+	delete(s.budgets, projectID)
+}
+
+// Budgets returns a project's budgets, oldest first.
+func (s *Server) Budgets(projectID string) []Budget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Budget{}, s.budgets[projectID]...)
+}
+
+func (s *Server) projectIDs() []string {
+	ids := make([]string, 0, len(s.projects))
+	for id := range s.projects {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	// Handlers parsing forms read the body again.
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	s.mu.Lock()
+	s.requests = append(s.requests, RecordedRequest{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Header: r.Header.Clone(),
+		Body:   string(body),
+	})
+	fault := s.matchFault(r)
+	s.mu.Unlock()
+
+	if fault != nil {
+		time.Sleep(fault.Latency)
+		if fault.Status != 0 {
+			writeError(w, fault.Status, fault.Body)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.settleBillingSwitches()
+
+	switch {
+	case r.URL.Path == "/token":
+		s.handleSignIn(w, r)
+		return
+	case r.URL.Path == "/oauth/token":
+		s.handleOAuthToken(w, r)
+		return
+	}
+
+	if !s.authenticated(r) {
+		writeError(w, http.StatusUnauthorized, "invalid or expired token")
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(segments) == 2 && segments[0] == "api" && segments[1] == "group_hierarchy":
+		s.handleHierarchy(w, r, body)
+	case len(segments) == 3 && segments[0] == "api" && segments[1] == "project":
+		s.handleProject(w, r, segments[2], body)
+	case len(segments) == 4 && segments[0] == "api" && segments[1] == "project" && segments[3] == "budgets" && r.Method == http.MethodGet:
+		s.handleListBudgets(w, r, segments[2])
+	case len(segments) == 4 && segments[0] == "api" && segments[3] == "add_budget" && r.Method == http.MethodPost:
+		s.handleAddBudget(w, segments[1], segments[2], body)
+	default:
+		writeError(w, http.StatusNotFound, "no such endpoint")
+	}
+}
+
+// matchFault returns the first fault matching r, consuming one of its Times.
+func (s *Server) matchFault(r *http.Request) *Fault {
+	for i, f := range s.faults {
+		if f.Method != "" && f.Method != r.Method {
+			continue
+		}
+		if f.Path != "" && !strings.HasPrefix(r.URL.Path, f.Path) {
+			continue
+		}
+
+		matched := *f
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return &matched
+	}
+
+	return nil
+}
+
+func (s *Server) issueToken() string {
+	s.tokenSeq++
+	token := fmt.Sprintf("token-%d", s.tokenSeq)
+	s.tokens[token] = true
+
+	return token
+}
+
+func (s *Server) authenticated(r *http.Request) bool {
+	token := r.Header.Get("x-access-token")
+	if bearer := r.Header.Get("Authorization"); strings.HasPrefix(bearer, "Bearer ") {
+		token = strings.TrimPrefix(bearer, "Bearer ")
+	}
+
+	return s.tokens[token]
+}
+
+func (s *Server) handleSignIn(w http.ResponseWriter, r *http.Request) {
+	username, password, ok := r.BasicAuth()
+	if r.Method != http.MethodPost || !ok || username != Username || password != Password {
+		writeError(w, http.StatusUnauthorized, "invalid username or password")
+		return
+	}
+
+	writeJSON(w, map[string]string{"token": s.issueToken()})
+}
+
+func (s *Server) handleOAuthToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Method != http.MethodPost || r.PostForm.Get("grant_type") != "client_credentials" {
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != ClientID || clientSecret != ClientSecret {
+		writeError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"access_token": s.issueToken(),
+		"token_type":   "bearer",
+		"expires_in":   int(s.TokenTTL.Seconds()),
+	})
+}
+
+func (s *Server) handleProject(w http.ResponseWriter, r *http.Request, projectID string, body []byte) {
+	switch r.Method {
+	case http.MethodGet:
+		project, ok := s.projects[projectID]
+		if !ok {
+			writeError(w, http.StatusNotFound, "project not found")
+			return
+		}
+		writeJSON(w, project)
+
+	case http.MethodPost:
+		project := Project{}
+		if existing, ok := s.projects[projectID]; ok {
+			project = *existing
+		}
+		update := Project{}
+		if err := json.Unmarshal(body, &update); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// Read-only fields are kept, everything else is replaced.
+		update.ProjectID = projectID
+		update.TotalBudget = project.TotalBudget
+		update.DepartmentName = ""
+		if update.AfterCredits == "" {
+			update.AfterCredits = "Suspend"
+		}
+		if s.BillingSwitchDelay > 0 && update.PaidBillingAccount != project.PaidBillingAccount {
+			s.switches = append(s.switches, billingSwitch{projectID: projectID, account: update.PaidBillingAccount, budget: -1, due: s.now().Add(s.BillingSwitchDelay)})
+			update.PaidBillingAccount = project.PaidBillingAccount
+		}
+		writeJSON(w, s.saveProject(update))
+
+	case http.MethodDelete:
+		if _, ok := s.projects[projectID]; !ok {
+			writeError(w, http.StatusNotFound, "project not found")
+			return
+		}
+		delete(s.projects, projectID)
+		delete(s.budgets, projectID)
+		writeJSON(w, map[string]string{"message": "deleted"})
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// saveProject stores project, moving it into its department, or into the
+// unaffiliated department if its department doesn't exist.
+func (s *Server) saveProject(project Project) *Project {
+	departmentName, ok := s.departmentName(project.DepartmentID)
+	if !ok {
+		project.DepartmentID = UnaffiliatedDepartmentID
+		departmentName, _ = s.departmentName(UnaffiliatedDepartmentID)
+	}
+	project.DepartmentName = departmentName
+	if project.TotalBudget == "" {
+		project.TotalBudget = "0"
+	}
+
+	s.projects[project.ProjectID] = &project
+
+	return &project
+}
+
+func (s *Server) departmentName(departmentID string) (string, bool) {
+	for _, group := range s.groups {
+		for _, department := range group.Departments {
+			if department.DepartmentID == departmentID {
+				return department.DepartmentName, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func (s *Server) handleListBudgets(w http.ResponseWriter, r *http.Request, projectID string) {
+	if _, ok := s.projects[projectID]; !ok {
+		writeError(w, http.StatusNotFound, "project not found")
+		return
+	}
+
+	items := make([]interface{}, 0, len(s.budgets[projectID]))
+	for _, budget := range s.budgets[projectID] {
+		items = append(items, budget)
+	}
+	s.writeList(w, r, items)
+}
+
+func (s *Server) handleAddBudget(w http.ResponseWriter, scope, entityID string, body []byte) {
+	project, ok := s.projects[entityID]
+	if scope != "project" || !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s not found", scope, entityID))
+		return
+	}
+
+	budget := Budget{}
+	if err := json.Unmarshal(body, &budget); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	today := s.now().Format("2006-01-02")
+	budget.DateIssued = today
+	budget.DateActivated = ""
+	budget.DateSuspended = ""
+	if budget.State == "" {
+		budget.State = "Future"
+	}
+	if budget.Currency == "" {
+		budget.Currency = DefaultCurrency
+	}
+
+	activate := budget.State == "Active"
+	if activate {
+		budget.State = "Future"
+	}
+	s.budgets[entityID] = append(s.budgets[entityID], budget)
+
+	if activate {
+		index := len(s.budgets[entityID]) - 1
+		if s.BillingSwitchDelay > 0 {
+			s.switches = append(s.switches, billingSwitch{projectID: entityID, account: budget.BillingAccountID, budget: index, due: s.now().Add(s.BillingSwitchDelay)})
+		} else {
+			s.activateBudget(entityID, index)
+		}
+	}
+
+	total := 0.0
+	for _, b := range s.budgets[entityID] {
+		total += b.Amount
+	}
+	project.TotalBudget = json.Number(strconv.FormatFloat(total, 'f', -1, 64))
+
+	writeJSON(w, map[string]string{"message": "budget added"})
+}
+
+// activateBudget makes a budget the active one. It consumes the current
+// active budget and switches the project's billing account.
+func (s *Server) activateBudget(projectID string, index int) {
+	today := s.now().Format("2006-01-02")
+	budgets := s.budgets[projectID]
+
+	for i := range budgets {
+		if budgets[i].State == "Active" {
+			budgets[i].State = "Consumed"
+			budgets[i].DateSuspended = today
+		}
+	}
+	budgets[index].State = "Active"
+	budgets[index].DateActivated = today
+	s.projects[projectID].PaidBillingAccount = budgets[index].BillingAccountID
+}
+
+// settleBillingSwitches completes the billing account switches that are due.
+func (s *Server) settleBillingSwitches() {
+	pending := s.switches[:0]
+	for _, sw := range s.switches {
+		project, ok := s.projects[sw.projectID]
+		switch {
+		case !ok:
+			// Deleted in the meantime.
+		case s.now().Before(sw.due):
+			pending = append(pending, sw)
+		case sw.budget >= 0 && sw.budget < len(s.budgets[sw.projectID]):
+			s.activateBudget(sw.projectID, sw.budget)
+		default:
+			project.PaidBillingAccount = sw.account
+		}
+	}
+	s.switches = pending
+}
+
+func (s *Server) handleHierarchy(w http.ResponseWriter, r *http.Request, body []byte) {
+	switch r.Method {
+	case http.MethodGet:
+		groups := s.hierarchy()
+		items := make([]interface{}, 0, len(groups))
+		for _, group := range groups {
+			items = append(items, group)
+		}
+		s.writeList(w, r, items)
+
+	case http.MethodPost:
+		groups := []Group{}
+		if err := json.Unmarshal(body, &groups); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// The posted hierarchy replaces the groups and departments,
+		// and moves the listed projects into their departments.
+		hasUnaffiliated := false
+		for _, group := range groups {
+			if group.GroupID == UnaffiliatedGroupID {
+				hasUnaffiliated = true
+			}
+		}
+		if !hasUnaffiliated {
+			groups = append(groups, s.groups[0])
+		}
+		moves := map[string]string{}
+		for gi := range groups {
+			for di := range groups[gi].Departments {
+				for _, project := range groups[gi].Departments[di].Projects {
+					moves[project.ProjectID] = groups[gi].Departments[di].DepartmentID
+				}
+				groups[gi].Departments[di].Projects = nil
+			}
+		}
+		s.groups = groups
+
+		for _, project := range s.projects {
+			if departmentID, ok := moves[project.ProjectID]; ok {
+				project.DepartmentID = departmentID
+			}
+			s.saveProject(*project)
+		}
+		writeJSON(w, s.hierarchy())
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// hierarchy returns the groups with each project listed under its department.
+func (s *Server) hierarchy() []Group {
+	groups := make([]Group, len(s.groups))
+	for gi, group := range s.groups {
+		groups[gi] = Group{GroupName: group.GroupName, GroupID: group.GroupID}
+		for _, department := range group.Departments {
+			projects := []HierarchyProject{}
+			for _, projectID := range s.projectIDs() {
+				project := s.projects[projectID]
+				if project.DepartmentID == department.DepartmentID {
+					projects = append(projects, HierarchyProject{ProjectID: project.ProjectID, ProjectName: project.ProjectName})
+				}
+			}
+			groups[gi].Departments = append(groups[gi].Departments, Department{
+				DepartmentName: department.DepartmentName,
+				DepartmentID:   department.DepartmentID,
+				Projects:       projects,
+			})
+		}
+	}
+
+	return groups
+}
+
+// writeList writes items as a bare array, or as one page of a
+// page/limit envelope if PageSize is set.
+func (s *Server) writeList(w http.ResponseWriter, r *http.Request, items []interface{}) {
+	if s.PageSize <= 0 {
+		writeJSON(w, items)
+		return
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	totalPages := (len(items) + s.PageSize - 1) / s.PageSize
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	start := (page - 1) * s.PageSize
+	end := start + s.PageSize
+	if start > len(items) {
+		start = len(items)
+	}
+	if end > len(items) {
+		end = len(items)
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"items":       items[start:end],
+		"page":        page,
+		"limit":       s.PageSize,
+		"total_pages": totalPages,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
@@ -0,0 +1,132 @@
+package burwoodportal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Profile - Named set of portal connection settings read from the credentials file
+type Profile struct {
+	Host     string
+	Username string
+	Password string
+	Token    string
+}
+
+// defaultCredentialsFile returns ~/.config/burwoodportal/credentials.
+func defaultCredentialsFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, ".config", "burwoodportal", "credentials"), nil
+}
+
+// loadProfile reads the named profile from the credentials file at path.
+func loadProfile(path, name string) (*Profile, error) {
+	path, err := expandHome(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open credentials file: %w", err)
+	}
+	defer f.Close()
+
+	profiles, err := parseProfiles(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse credentials file %s: %w", path, err)
+	}
+
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found in credentials file %s", name, path)
+	}
+
+	return profile, nil
+}
+
+// expandHome replaces a leading "~/" in path with the user's home directory.
+func expandHome(path string) (string, error) {
+	if !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, path[2:]), nil
+}
+
+// parseProfiles parses INI-style profiles:
+//
+//	[sandbox]
+//	host     = https://sandbox.example.com
+//	username = me@example.com
+//	password = secret
+//
+// Blank lines and lines starting with '#' or ';' are ignored.
+func parseProfiles(r io.Reader) (map[string]*Profile, error) {
+	profiles := map[string]*Profile{}
+	var current *Profile
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if name == "" {
+				return nil, fmt.Errorf("line %d: empty profile name", lineNumber)
+			}
+			current = &Profile{}
+			profiles[name] = current
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNumber)
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.Trim(strings.TrimSpace(parts[1]), `"`)
+
+		if current == nil {
+			return nil, fmt.Errorf("line %d: %s set outside of a [profile] section", lineNumber, key)
+		}
+
+		switch key {
+		case "host":
+			current.Host = value
+		case "username":
+			current.Username = value
+		case "password":
+			current.Password = value
+		case "token":
+			current.Token = value
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q", lineNumber, key)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
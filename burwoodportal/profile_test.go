@@ -0,0 +1,156 @@
+package burwoodportal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"burwoodportal/burwoodportal/portaltest"
+)
+
+func TestParseProfiles(t *testing.T) {
+	profiles, err := parseProfiles(strings.NewReader(`
+# Comments and blank lines are ignored.
+[default]
+host     = https://api.example.com
+username = me@example.com
+password = "quoted secret"
+
+; Token only.
+[ci]
+token = abc123
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]*Profile{
+		"default": {Host: "https://api.example.com", Username: "me@example.com", Password: "quoted secret"},
+		"ci":      {Token: "abc123"},
+	}
+	if !reflect.DeepEqual(profiles, want) {
+		t.Errorf("got profiles %+v, want %+v", profiles, want)
+	}
+}
+
+func TestParseProfiles_errors(t *testing.T) {
+	cases := map[string]string{
+		"empty name":        "[]\ntoken = abc",
+		"no section":        "token = abc",
+		"missing separator": "[default]\ntoken abc",
+		"unknown key":       "[default]\nsecret = abc",
+	}
+
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseProfiles(strings.NewReader(content)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+// Settings from the provider block and environment win over the profile,
+// and the profile's credentials are only used as a unit.
+func TestConfigureClient_profilePrecedence(t *testing.T) {
+	server := portaltest.NewServer()
+	defer server.Close()
+	other := portaltest.NewServer()
+	defer other.Close()
+
+	for _, envVar := range []string{"PORTAL_PROFILE", "PORTAL_CREDENTIALS_FILE", "PORTAL_USERNAME", "PORTAL_PASSWORD", "PORTAL_TOKEN"} {
+		t.Setenv(envVar, "")
+	}
+
+	credentialsFile := filepath.Join(t.TempDir(), "credentials")
+	err := os.WriteFile(credentialsFile, []byte(`
+[sandbox]
+host     = `+server.URL+`
+username = `+portaltest.Username+`
+password = `+portaltest.Password+`
+
+[wrong-password]
+host     = `+server.URL+`
+username = `+portaltest.Username+`
+password = wrong
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		config   providerConfig
+		env      map[string]string
+		wantHost string
+		// wantToken is the token the client must use, empty for a signed-in one.
+		wantToken string
+		wantError string
+	}{
+		"profile only": {
+			config:   providerConfig{Profile: "sandbox"},
+			wantHost: server.URL,
+		},
+		"profile from environment": {
+			env:      map[string]string{"PORTAL_PROFILE": "sandbox"},
+			wantHost: server.URL,
+		},
+		"provider host wins": {
+			config:   providerConfig{Profile: "sandbox", Host: other.URL, Token: other.IssueToken()},
+			wantHost: other.URL,
+		},
+		"provider token wins": {
+			config:    providerConfig{Profile: "wrong-password", Token: "provider-token"},
+			wantHost:  server.URL,
+			wantToken: "provider-token",
+		},
+		"environment token wins": {
+			config:    providerConfig{Profile: "wrong-password"},
+			env:       map[string]string{"PORTAL_TOKEN": "environment-token"},
+			wantHost:  server.URL,
+			wantToken: "environment-token",
+		},
+		"credentials are not mixed": {
+			config:    providerConfig{Profile: "sandbox", Username: portaltest.Username},
+			wantError: "Missing password",
+		},
+		"wrong profile password": {
+			config:    providerConfig{Profile: "wrong-password"},
+			wantError: "Unable to create Burwood client",
+		},
+		"profile not found": {
+			config:    providerConfig{Profile: "missing"},
+			wantError: "Unable to load profile",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			for envVar, value := range tc.env {
+				t.Setenv(envVar, value)
+			}
+			config := tc.config
+			config.CredentialsFile = credentialsFile
+
+			c, diags := configureClient(context.Background(), config, "test")
+			if tc.wantError != "" {
+				if !diags.HasError() || diags[0].Summary != tc.wantError {
+					t.Fatalf("got diagnostics %v, want %q", diags, tc.wantError)
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			if c.HostURL != tc.wantHost {
+				t.Errorf("host = %q, want %q", c.HostURL, tc.wantHost)
+			}
+			if tc.wantToken != "" && c.Token != tc.wantToken {
+				t.Errorf("token = %q, want %q", c.Token, tc.wantToken)
+			}
+		})
+	}
+}
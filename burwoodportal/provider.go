@@ -2,90 +2,551 @@ package burwoodportal
 
 import (
 	"context"
+	"fmt"
 	//"flag"
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"golang.org/x/oauth2/clientcredentials"
+	"os"
+	"strconv"
+	"time"
 	//"log"
 )
 
-// Provider -
+var oauthSchema = &schema.Resource{
+	Schema: map[string]*schema.Schema{
+		"token_url": &schema.Schema{
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "URL of the OAuth2 token endpoint.",
+		},
+		"client_id": &schema.Schema{
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "OAuth2 client ID.",
+		},
+		"client_secret": &schema.Schema{
+			Type:        schema.TypeString,
+			Required:    true,
+			Sensitive:   true,
+			Description: "OAuth2 client secret.",
+		},
+		"scopes": &schema.Schema{
+			Type:        schema.TypeList,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Description: "OAuth2 scopes to request.",
+		},
+	},
+}
+
+// Provider - Provider built without a release version, see New.
 func Provider() *schema.Provider {
+	return New("dev")()
+}
+
+// New - Plugin SDK provider factory, configured on its own, e.g. by tests and
+// sweepers. version is reported to the portal in the User-Agent header.
+// Terraform is served by ProviderServer instead.
+func New(version string) func() *schema.Provider {
+	return func() *schema.Provider {
+		p := providerSchema()
+		p.ConfigureContextFunc = func(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
+			// TerraformVersion is only known once Terraform has configured the provider.
+			return providerConfigure(ctx, d, p.UserAgent("terraform-provider-burwoodportal", version))
+		}
+
+		return p
+	}
+}
+
+func providerSchema() *schema.Provider {
 	return &schema.Provider{
-		ConfigureContextFunc: providerConfigure,
 		Schema: map[string]*schema.Schema{
 			"host": &schema.Schema{
 				Type:        schema.TypeString,
 				Optional:    true,
-				Default: "https://api.bcs.burwood.com",
-				Description: "Desired hostname. Only needed if interactions with non-production environments are desired.",
+				ValidateFunc: validation.IsURLWithScheme([]string{"http", "https"}),
+				Description: "Desired host URL. Only needed if interactions with non-production environments are desired. A path on the URL is used as a base path for all API endpoints, e.g. for an API gateway. Defaults to the profile's host if a profile is selected, otherwise https://api.bcs.burwood.com.",
+			},
+			"profile": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Name of a profile in the credentials file to read host and credentials from. Credentials given in the provider block or environment variables take precedence over the profile. Can also be set with the PORTAL_PROFILE environment variable.",
+			},
+			"credentials_file": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path of the credentials file holding named profiles. Defaults to ~/.config/burwoodportal/credentials. Can also be set with the PORTAL_CREDENTIALS_FILE environment variable.",
+			},
+			"strict_decoding": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Default: false. Compare API responses with the provider's models and emit warnings for unknown or missing fields, to catch portal API changes before they cause drift. Can also be set with the PORTAL_STRICT_DECODING environment variable.",
+			},
+			"fiscal_year_start": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateMonth,
+				Description:  "Default: '1'. Month (1-12) the organization's fiscal year starts in. Budgets with an expirationdate of 'end_of_fiscal_year' expire on the last day of the fiscal year they are issued in. Can also be set with the PORTAL_FISCAL_YEAR_START environment variable.",
+			},
+			"request_timeout": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDuration,
+				Description:  "Default: '10s'. Timeout for a single API request, as a duration string such as '30s' or '2m'. Raise this if large hierarchy responses time out.",
+			},
+			"proxy_url": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithScheme([]string{"http", "https", "socks5"}),
+				Description:  "HTTP(S) proxy to send API requests through. Defaults to the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.",
+			},
+			"ca_bundle": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Path to, or PEM content of, additional CA certificates to trust, e.g. a private CA signing the portal or proxy certificate. The system trust store is still used.",
+			},
+			"client_certificate": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"client_key"},
+				Description:  "Path to, or PEM content of, the client certificate presented for mutual TLS. Requires client_key. Can also be set with the PORTAL_CLIENT_CERTIFICATE environment variable.",
+			},
+			"client_key": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Sensitive:    true,
+				RequiredWith: []string{"client_certificate"},
+				Description:  "Path to, or PEM content of, the private key for client_certificate. Can also be set with the PORTAL_CLIENT_KEY environment variable.",
+			},
+			"min_tls_version": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"1.0", "1.1", "1.2", "1.3"}, false),
+				Description:  "Default: '1.2'. Minimum TLS version to accept. Valid values: '1.0', '1.1', '1.2' or '1.3'.",
+			},
+			"insecure_skip_verify": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "Default: false. Disables TLS certificate verification. WARNING! This exposes credentials and API traffic to interception. Only use for debugging.",
 			},
 			"username": &schema.Schema{
 				Type:        schema.TypeString,
-				Required:    true,
-				DefaultFunc: schema.EnvDefaultFunc("PORTAL_USERNAME", nil),
-				Description: "Burwood portal username used for authentication with the Burwood portal REST API.",
+				Optional:    true,
+				Description: "Burwood portal username used for authentication with the Burwood portal REST API. Can also be set with the PORTAL_USERNAME environment variable. Conflicts with token and oauth.",
 			},
 			"password": &schema.Schema{
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				Sensitive:   true,
-				DefaultFunc: schema.EnvDefaultFunc("PORTAL_PASSWORD", nil),
-				Description: "Burwood portal password used for authentication with the Burwood portal REST API.",
+				Description: "Burwood portal password used for authentication with the Burwood portal REST API. Can also be set with the PORTAL_PASSWORD environment variable. Conflicts with token and oauth.",
+			},
+			"token": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "Pre-issued Burwood portal API token. Sent as-is with every request, skipping username/password sign-in. Can also be set with the PORTAL_TOKEN environment variable. Conflicts with username, password and oauth.",
+			},
+			"oauth": &schema.Schema{
+				Type:        schema.TypeList,
+				Optional:    true,
+				MaxItems:    1,
+				Elem:        oauthSchema,
+				Description: "OAuth2 client-credentials configuration. When given, bearer tokens are obtained from the token URL and refreshed automatically. Conflicts with token, username and password.",
 			},
 		},
-		ResourcesMap: map[string]*schema.Resource{
-			"burwoodportal_projects": resourceProject(),
-		},
-		DataSourcesMap: map[string]*schema.Resource{
-			"burwoodportal_hierarchy":   dataSourceGroupHierarchy(),
-		},
+		// burwoodportal_projects and burwoodportal_hierarchy are served
+		// by the plugin framework provider.
+		ResourcesMap:   map[string]*schema.Resource{},
+		DataSourcesMap: map[string]*schema.Resource{},
+	}
+}
+
+func providerConfigure(ctx context.Context, d *schema.ResourceData, userAgent string) (interface{}, diag.Diagnostics) {
+	c, diags := configureClient(ctx, expandProviderConfig(d), userAgent)
+	if c == nil {
+		return nil, diags
+	}
+
+	return c, diags
+}
+
+// providerConfig - Provider block settings, shared by the plugin SDK and
+// plugin framework providers. Empty fields are not configured.
+type providerConfig struct {
+	Host               string
+	Profile            string
+	CredentialsFile    string
+	RequestTimeout     string
+	ProxyURL           string
+	CABundle           string
+	ClientCertificate  string
+	ClientKey          string
+	MinTLSVersion      string
+	InsecureSkipVerify bool
+	StrictDecoding     *bool
+	FiscalYearStart    string
+	Username           string
+	Password           string
+	Token              string
+	OAuth              *clientcredentials.Config
+}
+
+// expandProviderConfig reads the provider block from the plugin SDK.
+func expandProviderConfig(d *schema.ResourceData) providerConfig {
+	config := providerConfig{
+		Host:               d.Get("host").(string),
+		Profile:            d.Get("profile").(string),
+		CredentialsFile:    d.Get("credentials_file").(string),
+		FiscalYearStart:    d.Get("fiscal_year_start").(string),
+		RequestTimeout:     d.Get("request_timeout").(string),
+		ProxyURL:           d.Get("proxy_url").(string),
+		CABundle:           d.Get("ca_bundle").(string),
+		ClientCertificate:  d.Get("client_certificate").(string),
+		ClientKey:          d.Get("client_key").(string),
+		MinTLSVersion:      d.Get("min_tls_version").(string),
+		InsecureSkipVerify: d.Get("insecure_skip_verify").(bool),
+		Username:           d.Get("username").(string),
+		Password:           d.Get("password").(string),
+		Token:              d.Get("token").(string),
+		OAuth:              expandOAuthConfig(d.Get("oauth").([]interface{})),
+	}
+
+	// An explicit false must not fall back to PORTAL_STRICT_DECODING. GetOkExists
+	// is deprecated, but the only way to tell false from unset.
+	if v, ok := d.GetOkExists("strict_decoding"); ok {
+		strictDecoding := v.(bool)
+		config.StrictDecoding = &strictDecoding
 	}
+
+	return config
 }
 
-func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
-	// Set up and return an authenticated client for the Burwood portal api.
+// applyDefaults fills settings missing from the provider block from their
+// environment variables and defaults. The muxed providers must agree on the
+// prepared configuration, so defaults live here instead of in the schema.
+func (config *providerConfig) applyDefaults() diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for _, setting := range []struct {
+		value  *string
+		envVar string
+	}{
+		{&config.Profile, "PORTAL_PROFILE"},
+		{&config.CredentialsFile, "PORTAL_CREDENTIALS_FILE"},
+		{&config.ClientCertificate, "PORTAL_CLIENT_CERTIFICATE"},
+		{&config.ClientKey, "PORTAL_CLIENT_KEY"},
+		{&config.Username, "PORTAL_USERNAME"},
+		{&config.Password, "PORTAL_PASSWORD"},
+		{&config.Token, "PORTAL_TOKEN"},
+		{&config.FiscalYearStart, "PORTAL_FISCAL_YEAR_START"},
+	} {
+		if *setting.value == "" {
+			*setting.value = os.Getenv(setting.envVar)
+		}
+	}
+
+	if config.StrictDecoding == nil {
+		strictDecoding := false
+		if v := os.Getenv("PORTAL_STRICT_DECODING"); v != "" {
+			var err error
+			strictDecoding, err = strconv.ParseBool(v)
+			if err != nil {
+				diags = append(diags, errorDiagnostic("Invalid PORTAL_STRICT_DECODING", err, cty.GetAttrPath("strict_decoding")))
+			}
+		}
+		config.StrictDecoding = &strictDecoding
+	}
+
+	if config.RequestTimeout == "" {
+		config.RequestTimeout = DefaultRequestTimeout.String()
+	}
+	if config.MinTLSVersion == "" {
+		config.MinTLSVersion = "1.2"
+	}
+	if config.FiscalYearStart == "" {
+		config.FiscalYearStart = "1"
+	}
+	if _, errs := validateMonth(config.FiscalYearStart, "fiscal_year_start"); len(errs) > 0 {
+		diags = append(diags, errorDiagnostic("Invalid fiscal_year_start", errs[0], cty.GetAttrPath("fiscal_year_start")))
+	}
+
+	return diags
+}
+
+// configureClient sets up and returns an authenticated client for the Burwood portal api.
+func configureClient(ctx context.Context, config providerConfig, userAgent string) (*Client, diag.Diagnostics) {
+	// Warning or errors can be collected in a slice type
+	diags := config.applyDefaults()
+	if diags.HasError() {
+		return nil, diags
+	}
 
 	// Credential config
-	username := d.Get("username").(string)
-	password := d.Get("password").(string)
+	username := config.Username
+	password := config.Password
+	token := config.Token
+	oauthConfig := config.OAuth
 
 	//  Host config
 	var host *string
-	hVal, ok := d.GetOk("host")
-	if ok {
-		tempHost := hVal.(string)
-		host = &tempHost
+	if config.Host != "" {
+		host = &config.Host
 	}
 
-	// Warning or errors can be collected in a slice type
-	var diags diag.Diagnostics
+	// Profile config. The provider block and environment variables win;
+	// the profile only fills in the host and, as a unit, the credentials.
+	if profileName := config.Profile; profileName != "" {
+		credentialsFile := config.CredentialsFile
+		if credentialsFile == "" {
+			var err error
+			credentialsFile, err = defaultCredentialsFile()
+			if err != nil {
+				diags = append(diags, errorDiagnostic("Unable to locate credentials file", err, cty.GetAttrPath("credentials_file")))
 
-	// Initialize the cilent or handle errors
-	if (username != "") && (password != "") {
-		c, err := NewClient(host, &username, &password)
+				return nil, diags
+			}
+		}
+
+		profile, err := loadProfile(credentialsFile, profileName)
 		if err != nil {
+			diags = append(diags, errorDiagnostic("Unable to load profile", err, cty.GetAttrPath("profile")))
+
+			return nil, diags
+		}
+
+		if host == nil && profile.Host != "" {
+			host = &profile.Host
+		}
+
+		if username == "" && password == "" && token == "" && oauthConfig == nil {
+			username = profile.Username
+			password = profile.Password
+			token = profile.Token
+		}
+	}
+
+	if host == nil {
+		defaultHost := HostURL
+		host = &defaultHost
+	}
+
+	if _, err := ParseHostURL(*host); err != nil {
+		diags = append(diags, errorDiagnostic("Invalid host", err, cty.GetAttrPath("host")))
+
+		return nil, diags
+	}
+
+	fiscalYearMonth, _ := strconv.Atoi(config.FiscalYearStart)
+	fiscalYearStart := time.Month(fiscalYearMonth)
+
+	// Transport config
+	timeout, _ := time.ParseDuration(config.RequestTimeout)
+	transportConfig := TransportConfig{
+		Timeout:            timeout,
+		ProxyURL:           config.ProxyURL,
+		CABundle:           config.CABundle,
+		MinTLSVersion:      config.MinTLSVersion,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+		UserAgent:          userAgent,
+	}
+
+	if transportConfig.InsecureSkipVerify {
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Warning,
+			Summary:       "TLS certificate verification is DISABLED",
+			Detail:        "insecure_skip_verify is set. The portal's identity is not verified and credentials and API traffic can be intercepted. Do not use this outside of debugging; configure ca_bundle for private CAs instead.",
+			AttributePath: cty.GetAttrPath("insecure_skip_verify"),
+		})
+	}
+
+	clientCertificate := config.ClientCertificate
+	clientKey := config.ClientKey
+	if clientCertificate != "" || clientKey != "" {
+		if clientCertificate == "" || clientKey == "" {
 			diags = append(diags, diag.Diagnostic{
 				Severity: diag.Error,
-				Summary:  "Unable to create Burwood client",
-				Detail:   "Unable to authenticate user for authenticated Burwood client",
+				Summary:  "Incomplete client certificate configuration",
+				Detail:   "client_certificate and client_key must be configured together.",
 			})
 
 			return nil, diags
 		}
 
+		certificate, err := LoadClientCertificate(clientCertificate, clientKey)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Invalid client certificate",
+				Detail:        fmt.Sprintf("Unable to load the client_certificate and client_key pair. Check that both are PEM encoded and that the key belongs to the certificate: %s", err),
+				AttributePath: cty.GetAttrPath("client_key"),
+			})
+
+			return nil, diags
+		}
+		transportConfig.ClientCertificate = certificate
+	}
+
+	httpClient, err := NewHTTPClient(transportConfig)
+	if err != nil {
+		diags = append(diags, errorDiagnostic("Unable to configure HTTP transport", err, nil))
+
+		return nil, diags
+	}
+
+	diags = append(diags, validateAuthMethod(username, password, token, oauthConfig != nil)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	// OAuth2 client credentials
+	if oauthConfig != nil {
+		c, err := NewClientWithOAuth(ctx, host, oauthConfig, httpClient)
+		if err != nil {
+			diags = append(diags, errorDiagnostic("Unable to create Burwood client", err, cty.GetAttrPath("oauth")))
+
+			return nil, diags
+		}
+		c.StrictDecoding = *config.StrictDecoding
+		c.FiscalYearStart = fiscalYearStart
+
+		return c, diags
+	}
+
+	// Pre-issued token, no sign-in needed
+	if token != "" {
+		c, err := NewClientWithToken(ctx, host, &token, httpClient)
+		if err != nil {
+			diags = append(diags, errorDiagnostic("Unable to create Burwood client", err, cty.GetAttrPath("token")))
+
+			return nil, diags
+		}
+		c.StrictDecoding = *config.StrictDecoding
+		c.FiscalYearStart = fiscalYearStart
+
 		return c, diags
 	}
 
-	c, err := NewClient(host, nil, nil)
+	// Username and password, sign in for a token
+	c, err := NewClient(ctx, host, &username, &password, httpClient)
 	if err != nil {
 		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Unable to create Burwood client",
-			Detail:   "Unable to create anonymous Burwood client",
+			Severity:      diag.Error,
+			Summary:       "Unable to create Burwood client",
+			Detail:        fmt.Sprintf("Unable to authenticate user for authenticated Burwood client: %s", errorDetail(err)),
+			AttributePath: cty.GetAttrPath("username"),
 		})
+
 		return nil, diags
 	}
+	c.StrictDecoding = *config.StrictDecoding
+	c.FiscalYearStart = fiscalYearStart
 
 	return c, diags
+}
+
+// expandOAuthConfig converts the oauth block into a client-credentials config.
+// Returns nil when the block is not configured.
+func expandOAuthConfig(l []interface{}) *clientcredentials.Config {
+	if len(l) == 0 || l[0] == nil {
+		return nil
+	}
+
+	o := l[0].(map[string]interface{})
+	scopes := []string{}
+	for _, scope := range o["scopes"].([]interface{}) {
+		scopes = append(scopes, scope.(string))
+	}
+
+	return &clientcredentials.Config{
+		TokenURL:     o["token_url"].(string),
+		ClientID:     o["client_id"].(string),
+		ClientSecret: o["client_secret"].(string),
+		Scopes:       scopes,
+	}
+}
+
+// validateDuration checks that a string attribute parses as a positive time.Duration.
+func validateDuration(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %s to be string", k))
+		return warnings, errors
+	}
+
+	duration, err := time.ParseDuration(v)
+	if err != nil {
+		errors = append(errors, fmt.Errorf("expected %s to be a duration such as '30s', got %q: %v", k, v, err))
+		return warnings, errors
+	}
+	if duration <= 0 {
+		errors = append(errors, fmt.Errorf("expected %s to be a positive duration, got %q", k, v))
+	}
+
+	return warnings, errors
+}
+
+// validateMonth checks that a string attribute is a month number from 1 to 12.
+func validateMonth(i interface{}, k string) (warnings []string, errors []error) {
+	v, ok := i.(string)
+	if !ok {
+		errors = append(errors, fmt.Errorf("expected type of %s to be string", k))
+		return warnings, errors
+	}
+
+	month, err := strconv.Atoi(v)
+	if err != nil || month < 1 || month > 12 {
+		errors = append(errors, fmt.Errorf("expected %s to be a month from 1 to 12, got %q", k, v))
+	}
+
+	return warnings, errors
+}
+
+// validateAuthMethod checks that exactly one authentication method is configured:
+// an oauth block, a token, or a username and password pair.
+func validateAuthMethod(username, password, token string, hasOAuth bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	hasCredentials := username != "" || password != ""
+
+	methods := 0
+	for _, configured := range []bool{hasCredentials, token != "", hasOAuth} {
+		if configured {
+			methods++
+		}
+	}
+
+	switch {
+	case methods > 1:
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Conflicting authentication methods",
+			Detail:   "More than one of oauth, token and username/password is configured. Configure exactly one authentication method, either in the provider block or via the PORTAL_TOKEN or PORTAL_USERNAME/PORTAL_PASSWORD environment variables.",
+		})
+	case methods == 0:
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  "Missing authentication method",
+			Detail:   "Configure either an oauth block, a token, or a username and password, in the provider block or via the PORTAL_TOKEN or PORTAL_USERNAME/PORTAL_PASSWORD environment variables.",
+		})
+	case hasCredentials && username == "":
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Error,
+			Summary:       "Missing username",
+			Detail:        "A password was configured without a username.",
+			AttributePath: cty.GetAttrPath("username"),
+		})
+	case hasCredentials && password == "":
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Error,
+			Summary:       "Missing password",
+			Detail:        "A username was configured without a password.",
+			AttributePath: cty.GetAttrPath("password"),
+		})
+	}
 
+	return diags
 }
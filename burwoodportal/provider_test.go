@@ -0,0 +1,286 @@
+package burwoodportal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"burwoodportal/burwoodportal/portaltest"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// Prefix of everything acceptance tests create in the portal, and of what
+// the sweepers delete. PORTAL_ACC_PREFIX overrides it, e.g. to keep
+// concurrent CI runs against one sandbox apart.
+var testAccPrefix = testAccPrefixFromEnv()
+
+func testAccPrefixFromEnv() string {
+	if prefix := os.Getenv("PORTAL_ACC_PREFIX"); prefix != "" {
+		return prefix
+	}
+
+	return "tf-acc-"
+}
+
+// Acceptance tests run the muxed provider server, like Terraform does.
+var testAccProtoV5ProviderFactories = map[string]func() (tfprotov5.ProviderServer, error){
+	"burwoodportal": func() (tfprotov5.ProviderServer, error) {
+		providerServer, err := ProviderServer(context.Background(), "test")
+		if err != nil {
+			return nil, err
+		}
+		return providerServer(), nil
+	},
+}
+
+func TestProvider(t *testing.T) {
+	if err := Provider().InternalValidate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProviderServer(t *testing.T) {
+	ctx := context.Background()
+	providerServer, err := ProviderServer(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := providerServer()
+
+	// The mux reports differences between the SDK and framework provider schemas.
+	schemaResp, err := server.GetProviderSchema(ctx, &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range schemaResp.Diagnostics {
+		t.Errorf("provider schema: %s: %s", d.Summary, d.Detail)
+	}
+	if _, ok := schemaResp.DataSourceSchemas["burwoodportal_hierarchy"]; !ok {
+		t.Error("burwoodportal_hierarchy data source not served")
+	}
+
+	// State written by earlier, plugin SDK-only releases must still load.
+	stateResp, err := server.UpgradeResourceState(ctx, &tfprotov5.UpgradeResourceStateRequest{
+		TypeName: "burwoodportal_projects",
+		Version:  0,
+		RawState: &tfprotov5.RawState{JSON: []byte(`{
+			"id": "my-project",
+			"projectid": "my-project",
+			"projectname": "My Project",
+			"aftercredits": "Suspend",
+			"departmentid": "department",
+			"departmentname": "Department",
+			"totalbudget": "100",
+			"recurringbudget": false,
+			"latestbudget": [{"amount": 100, "billingaccountid": "000000-000000-000000", "state": "Active", "recurring": false}]
+		}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range stateResp.Diagnostics {
+		t.Errorf("upgrading burwoodportal_projects state: %s: %s", d.Summary, d.Detail)
+	}
+}
+
+// The muxed server configures one client: one sign-in, one error per problem.
+func TestProviderServer_configure(t *testing.T) {
+	ctx := context.Background()
+	portal := portaltest.NewServer()
+	defer portal.Close()
+	for _, envVar := range []string{"PORTAL_PROFILE", "PORTAL_USERNAME", "PORTAL_PASSWORD", "PORTAL_TOKEN"} {
+		t.Setenv(envVar, "")
+	}
+
+	providerServer, err := ProviderServer(ctx, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := providerServer()
+
+	schemaResp, err := server.GetProviderSchema(ctx, &tfprotov5.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	configType := schemaResp.Provider.ValueType().(tftypes.Object)
+
+	configure := func(password string) []*tfprotov5.Diagnostic {
+		t.Helper()
+
+		attrs := map[string]tftypes.Value{}
+		for name, attrType := range configType.AttributeTypes {
+			attrs[name] = tftypes.NewValue(attrType, nil)
+		}
+		attrs["host"] = tftypes.NewValue(tftypes.String, portal.URL)
+		attrs["username"] = tftypes.NewValue(tftypes.String, portaltest.Username)
+		attrs["password"] = tftypes.NewValue(tftypes.String, password)
+		attrs["oauth"] = tftypes.NewValue(configType.AttributeTypes["oauth"], []tftypes.Value{})
+
+		config, err := tfprotov5.NewDynamicValue(configType, tftypes.NewValue(configType, attrs))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := server.ConfigureProvider(ctx, &tfprotov5.ConfigureProviderRequest{TerraformVersion: "1.5.7", Config: &config})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp.Diagnostics
+	}
+
+	signIns := func() int {
+		count := 0
+		for _, request := range portal.Requests() {
+			if request.Path == "/token" {
+				count++
+			}
+		}
+		return count
+	}
+
+	if diags := configure(portaltest.Password); len(diags) != 0 {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if got := signIns(); got != 1 {
+		t.Errorf("configuring signed in %d times, want once", got)
+	}
+
+	if diags := configure("wrong"); len(diags) != 1 {
+		t.Errorf("wrong password: got %d diagnostics, want 1: %v", len(diags), diags)
+	}
+}
+
+// testAccPortal - Portal an acceptance test runs against.
+// By default that's a fresh portaltest fake. Setting PORTAL_HOST, plus credentials
+// through the provider's environment variables, runs against a real sandbox instead:
+//
+//	PORTAL_ACC_DEPARTMENT_IDS    two comma-separated departments to create projects in
+//	PORTAL_ACC_BILLING_ACCOUNT   billing account for test budgets
+type testAccPortal struct {
+	// Server is the fake, nil against a sandbox.
+	Server           *portaltest.Server
+	DepartmentIDs    []string
+	BillingAccountID string
+
+	providerAttrs map[string]interface{}
+}
+
+func newTestAccPortal(t *testing.T) *testAccPortal {
+	t.Helper()
+
+	if host := os.Getenv("PORTAL_HOST"); host != "" {
+		portal := &testAccPortal{
+			DepartmentIDs:    strings.Split(os.Getenv("PORTAL_ACC_DEPARTMENT_IDS"), ","),
+			BillingAccountID: os.Getenv("PORTAL_ACC_BILLING_ACCOUNT"),
+			providerAttrs:    map[string]interface{}{"host": host},
+		}
+		if len(portal.DepartmentIDs) != 2 || portal.BillingAccountID == "" {
+			t.Fatal("PORTAL_ACC_DEPARTMENT_IDS (two departments) and PORTAL_ACC_BILLING_ACCOUNT must be set for acceptance tests against PORTAL_HOST")
+		}
+
+		return portal
+	}
+
+	server := portaltest.NewServer()
+	t.Cleanup(server.Close)
+	server.AddGroup(portaltest.Group{
+		GroupName: "Acceptance Tests",
+		GroupID:   testAccPrefix + "group",
+		Departments: []portaltest.Department{
+			{DepartmentName: "Acceptance Tests A", DepartmentID: testAccPrefix + "department-a"},
+			{DepartmentName: "Acceptance Tests B", DepartmentID: testAccPrefix + "department-b"},
+		},
+	})
+
+	return &testAccPortal{
+		Server:           server,
+		DepartmentIDs:    []string{testAccPrefix + "department-a", testAccPrefix + "department-b"},
+		BillingAccountID: "000000-000000-000000",
+		providerAttrs: map[string]interface{}{
+			"host":     server.URL,
+			"username": portaltest.Username,
+			"password": portaltest.Password,
+		},
+	}
+}
+
+// ProviderConfig returns the provider block pointing at the portal.
+func (p *testAccPortal) ProviderConfig() string {
+	keys := make([]string, 0, len(p.providerAttrs))
+	for key := range p.providerAttrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	config := "provider \"burwoodportal\" {\n"
+	for _, key := range keys {
+		config += fmt.Sprintf("  %s = %q\n", key, p.providerAttrs[key])
+	}
+
+	return config + "}\n"
+}
+
+// Client returns a client configured like the provider under test,
+// for checks and out-of-band changes.
+func (p *testAccPortal) Client(t *testing.T) *Client {
+	t.Helper()
+
+	provider := Provider()
+	if diags := provider.Configure(context.Background(), terraform.NewResourceConfigRaw(p.providerAttrs)); diags.HasError() {
+		t.Fatalf("configuring provider: %v", diags)
+	}
+
+	return provider.Meta().(*Client)
+}
+
+// testAccProjectID returns a random project ID with the test prefix.
+func testAccProjectID() string {
+	return testAccPrefix + acctest.RandStringFromCharSet(10, acctest.CharSetAlphaNum)
+}
+
+// newCassetteClient returns a client replaying testdata/cassettes/<test name>.json.
+// With PORTAL_CASSETTE_MODE=record it instead records the cassette against the
+// portal newTestAccPortal selects, after setup has created what the test reads.
+// Requests made by setup are not recorded.
+func newCassetteClient(t *testing.T, setup func(portal *testAccPortal, c *Client)) *Client {
+	t.Helper()
+
+	mode, err := portaltest.CassetteModeFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var c *Client
+	if mode == portaltest.ModeRecord {
+		portal := newTestAccPortal(t)
+		c = portal.Client(t)
+		setup(portal, c)
+	} else {
+		// Replayed requests never leave the process.
+		host, token := "https://portal.invalid", "replay"
+		c, err = NewClientWithToken(context.Background(), &host, &token, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recorder, err := portaltest.NewRecorder(filepath.Join("testdata", "cassettes", t.Name()+".json"), mode, c.HTTPClient.Transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Transport = recorder
+	t.Cleanup(func() {
+		if err := recorder.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return c
+}
@@ -0,0 +1,90 @@
+package burwoodportal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// requestOptions - Per-call settings for do and forEachListItem.
+type requestOptions struct {
+	header http.Header
+}
+
+// requestOption - Functional option for do and forEachListItem.
+type requestOption func(*requestOptions)
+
+// withHeader sets a request header, e.g. Basic auth on sign-in.
+func withHeader(key, value string) requestOption {
+	return func(o *requestOptions) {
+		o.header.Set(key, value)
+	}
+}
+
+// noContent - Response type for calls whose response body is ignored.
+// The body isn't decoded at all, so it doesn't even need to be JSON.
+type noContent struct{}
+
+// do sends a request to the API endpoint made of segments, JSON encoding
+// body unless it is nil, and decodes the response into a T.
+// An empty response body decodes to the zero T.
+func do[T any](ctx context.Context, c *Client, method string, segments []string, body interface{}, options ...requestOption) (T, error) {
+	var result T
+
+	o := newRequestOptions(options)
+
+	var requestBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return result, fmt.Errorf("unable to encode %s request body: %w", method, err)
+		}
+		requestBody = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.endpointURL(segments...), requestBody)
+	if err != nil {
+		return result, err
+	}
+	for key, values := range o.header {
+		req.Header[key] = values
+	}
+
+	responseBody, _, err := c.doRequestWithHeader(req, nil)
+	if err != nil {
+		return result, err
+	}
+
+	if _, ignored := interface{}(&result).(*noContent); ignored {
+		return result, nil
+	}
+
+	if err := decodeJSON(responseBody, &result); err != nil {
+		return result, fmt.Errorf("unable to decode %s %s response: %w", method, req.URL.Path, err)
+	}
+	c.checkContract(ctx, fmt.Sprintf("%s %s", method, req.URL.Path), responseBody, reflect.TypeOf(result))
+
+	return result, nil
+}
+
+func newRequestOptions(options []requestOption) *requestOptions {
+	o := &requestOptions{header: http.Header{}}
+	for _, option := range options {
+		option(o)
+	}
+
+	return o
+}
+
+// decodeJSON decodes data into v, leaving v as is if data is empty.
+func decodeJSON(data []byte, v interface{}) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+
+	return json.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
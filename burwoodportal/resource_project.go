@@ -2,419 +2,964 @@ package burwoodportal
 
 import (
 	"context"
-	"fmt"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/objectvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
-var budgetSchema = &schema.Resource{
-	Schema: map[string]*schema.Schema{
-		"ponumber": &schema.Schema{
-			Type:     schema.TypeString,
-			Optional: true,
-			Description: "PO to use for this budget (custom terminology for this field may be present in the portal UI, e.g. ChartField)",
-		},
-		"grant": &schema.Schema{
-			Type:     schema.TypeString,
-			Optional: true,
-			Description: "Grant to use for this budget.",
-		},
-		"amount": &schema.Schema{
-			Type:     schema.TypeFloat,
-			Required: true,
-			Description: "Dollar amount to use for the budget. Acts as a float data type (decimals allowed).",
-		},
-		"billingaccountid": &schema.Schema{
-			Type:     schema.TypeString,
-			Required: true,
-			Description: "GCP billing account ID to use for consumption on this budget.",
-		},
-		"expirationdate": &schema.Schema{
-			Type:     schema.TypeString,
-			Optional: true,
-			Description: "YYYY-MM-DD format. Date after which to mark the budget as consumed regardless of spend on it.",
-		},
-		"dateissued": &schema.Schema{
-			Type:     schema.TypeString,
-			Computed: true,
-			Description: "YYYY-MM-DD format. Budget issue date. Used in budget alerting emails.",
-		},
-		"dateactivated": &schema.Schema{
-			Type:     schema.TypeString,
-			Computed: true,
-			Description: "Budget activation date. Date on which the budget activate its billing account and tracking consumption.",
-		},
-		"datesuspended": &schema.Schema{
-			Type:     schema.TypeString,
-			Computed: true,
-			Description: "Date on which the budget was deactivate and marked consumed.",
-		},
-		"state": &schema.Schema {
-			Type:	schema.TypeString,
-			Default: "Future",
-			Optional: true,
-			Description: "Valid values are 'Active' and 'Future'. WARNING! If set to 'Active', this budget will mark existing active budgets as consumed and set the GCP project's billing account to the specified billingaccountid!",
+// projectResource - burwoodportal_projects, a portal project and its latest budget.
+type projectResource struct {
+	client *Client
+}
+
+type projectResourceModel struct {
+	ID                  types.String         `tfsdk:"id"`
+	ProjectID           types.String         `tfsdk:"projectid"`
+	ProjectName         types.String         `tfsdk:"projectname"`
+	PrimaryContactEmail types.String         `tfsdk:"primarycontactemail"`
+	BillingContactEmail types.String         `tfsdk:"billingcontactemail"`
+	AfterCredits        types.String         `tfsdk:"aftercredits"`
+	AfterCreditsAccount types.String         `tfsdk:"aftercreditsaccount"`
+	AfterCreditsPO      types.String         `tfsdk:"aftercreditspo"`
+	PaidBillingAccount  types.String         `tfsdk:"paidbillingaccount"`
+	TotalBudget         types.Number         `tfsdk:"totalbudget"`
+	TotalBudgetCurrency types.String         `tfsdk:"totalbudget_currency"`
+	RecurringBudget     types.Bool           `tfsdk:"recurringbudget"`
+	DepartmentID        types.String         `tfsdk:"departmentid"`
+	DepartmentName      types.String         `tfsdk:"departmentname"`
+	OnDestroy           types.String         `tfsdk:"on_destroy"`
+	LatestBudget        *budgetResourceModel `tfsdk:"latestbudget"`
+	Timeouts            timeouts.Value       `tfsdk:"timeouts"`
+}
+
+type budgetResourceModel struct {
+	PONumber         types.String `tfsdk:"ponumber"`
+	Grant            types.String `tfsdk:"grant"`
+	Amount           types.Number `tfsdk:"amount"`
+	Currency         types.String `tfsdk:"currency"`
+	BillingAccountID types.String `tfsdk:"billingaccountid"`
+	ExpirationDate   types.String `tfsdk:"expirationdate"`
+	DateIssued       types.String `tfsdk:"dateissued"`
+	DateActivated    types.String `tfsdk:"dateactivated"`
+	DateSuspended    types.String `tfsdk:"datesuspended"`
+	State            types.String `tfsdk:"state"`
+	Recurring        types.Bool   `tfsdk:"recurring"`
+}
+
+// Default operation timeouts. Activating a budget switches the project's GCP
+// billing account, which can take minutes.
+const (
+	defaultProjectCreateTimeout = 20 * time.Minute
+	defaultProjectReadTimeout   = 5 * time.Minute
+	defaultProjectUpdateTimeout = 20 * time.Minute
+	defaultProjectDeleteTimeout = 10 * time.Minute
+)
+
+// Private state key recording whether the latest budget's currency was
+// configured, or is the portal's default read back, see ModifyPlan.
+const currencyConfiguredKey = "latestbudget_currency_configured"
+
+// Private state key holding the latest budget's postedExpiration.
+const postedExpirationKey = "latestbudget_expiration"
+
+// postedExpiration - The latest budget's expirationdate value and the date it
+// was posted with. Relative expirations resolve from the provider's clock, which
+// can be on another day than the portal's dateissued, so reads compare the
+// portal's expiration date with the posted one as well.
+type postedExpiration struct {
+	Value string `json:"value"`
+	Date  string `json:"date"`
+}
+
+// matches reports whether date is the one value was posted with.
+func (p *postedExpiration) matches(value, date string) bool {
+	return p != nil && p.Value == value && p.Date != "" && sameExpiration(p.Date, date, "", time.January)
+}
+
+// on_destroy modes.
+const (
+	onDestroyDelete   = "delete"
+	onDestroyUnassign = "unassign"
+	onDestroyAbandon  = "abandon"
+)
+
+// NewProjectResource - burwoodportal_projects factory.
+func NewProjectResource() resource.Resource {
+	return &projectResource{}
+}
+
+func (r *projectResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_projects"
+}
+
+// Schema version 1 turned totalbudget into a number and latestbudget into a
+// single nested block, see UpgradeState.
+func (r *projectResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Version: 1,
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:      true,
+				Description:   "The GCP project ID.",
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"projectid": schema.StringAttribute{
+				Required:    true,
+				Description: "GCP Project ID",
+			},
+			"projectname": schema.StringAttribute{
+				Optional:    true,
+				Description: "Project name  as shown in the portal.",
+			},
+			"primarycontactemail": schema.StringAttribute{
+				Optional:    true,
+				Description: "The project primary contact email address.",
+			},
+			"billingcontactemail": schema.StringAttribute{
+				Optional:    true,
+				Description: "Primary billing contact email.",
+			},
+			"aftercredits": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("Suspend"),
+				Description: "Default: 'Suspend'. Valid values: 'Bill' or 'Suspend'. Only set to bill if post-budget free spend is desired.",
+			},
+			"aftercreditsaccount": schema.StringAttribute{
+				Optional:    true,
+				Description: "GCP billing account to use for post-credit consumption. Only applies if aftercredits is set to 'Suspend' ",
+			},
+			"aftercreditspo": schema.StringAttribute{
+				Optional:    true,
+				Description: "Purchase Order for afterCredits consumption.",
+			},
+			"paidbillingaccount": schema.StringAttribute{
+				Optional:      true,
+				Computed:      true,
+				Description:   "The project GCP billing account ID. WARNING! This will change the project's billing account in GCP! If not given, activating a budget switches it to the budget's billing account.",
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"totalbudget": schema.NumberAttribute{
+				Computed:    true,
+				Description: "Total budget amount on the project as reported by the portal, in totalbudget_currency.",
+			},
+			"totalbudget_currency": schema.StringAttribute{
+				Computed:    true,
+				Description: "ISO 4217 currency code of totalbudget. Null if the project's budgets are in different currencies, as the portal then adds up amounts in different currencies.",
+			},
+			"recurringbudget": schema.BoolAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     booldefault.StaticBool(false),
+				Description: "Default: false. Whether project budgets should recur on a monthly basis.",
+			},
+			"departmentid": schema.StringAttribute{
+				Required:    true,
+				Description: "Department ID to for the project. If invalid or not given, the project will be placed into the 'Unaffiliated Projects' department. Department names and ID's can be seen in the group hierarchy data source, and a code example can be seen in the guides.",
+			},
+			"departmentname": schema.StringAttribute{
+				Computed:    true,
+				Description: "Department name that the project is under.",
+			},
+			"on_destroy": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(onDestroyDelete),
+				Validators:  []validator.String{stringvalidator.OneOf(onDestroyDelete, onDestroyUnassign, onDestroyAbandon)},
+				Description: "Default: 'delete'. What destroying the resource does to the portal project. Valid values: 'delete' deletes it, 'unassign' moves it into the 'Unaffiliated Projects' department, and 'abandon' only removes it from Terraform state.",
+			},
 		},
-		"recurring": &schema.Schema {
-			Type:	schema.TypeBool,
-			Default: false,
-			Optional: true,
-			Description: "Boolean; whether the budget should be a recurring monthly budget or a standard budget.",
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create:            true,
+				Read:              true,
+				Update:            true,
+				Delete:            true,
+				CreateDescription: "Default: '20m'. Timeout for creating the project and its budget, as a duration string such as '30s' or '2h45m'.",
+				ReadDescription:   "Default: '5m'. Timeout for reading the project during refresh, as a duration string such as '30s' or '2h45m'.",
+				UpdateDescription: "Default: '20m'. Timeout for updating the project and appending a budget, as a duration string such as '30s' or '2h45m'.",
+				DeleteDescription: "Default: '10m'. Timeout for deleting the project, as a duration string such as '30s' or '2h45m'.",
+			}),
+			"latestbudget": schema.SingleNestedBlock{
+				Description: "Most recently added budget. If given as a subblock, a new budget will be appended to the prjoect whenever the block changes. See the budget schema for more details.",
+				// Required attributes would make the block itself required.
+				Validators: []validator.Object{
+					objectvalidator.AlsoRequires(
+						path.MatchRelative().AtName("amount"),
+						path.MatchRelative().AtName("billingaccountid"),
+					),
+				},
+				Attributes: map[string]schema.Attribute{
+					"ponumber": schema.StringAttribute{
+						Optional:    true,
+						Description: "PO to use for this budget (custom terminology for this field may be present in the portal UI, e.g. ChartField)",
+					},
+					"grant": schema.StringAttribute{
+						Optional:    true,
+						Description: "Grant to use for this budget.",
+					},
+					"amount": schema.NumberAttribute{
+						Optional:    true,
+						Description: "Required. Amount to use for the budget, in its currency. Exact decimal, e.g. 1337.50.",
+					},
+					// Not configured, the planned currency is set by ModifyPlan.
+					"currency": schema.StringAttribute{
+						Optional:    true,
+						Computed:    true,
+						Description: "ISO 4217 currency code of the amount, e.g. 'EUR'. Defaults to the portal's currency, which is read back when not given.",
+						Validators:  []validator.String{currencyValidator{}},
+					},
+					"billingaccountid": schema.StringAttribute{
+						Optional:    true,
+						Description: "Required. GCP billing account ID to use for consumption on this budget.",
+					},
+					"expirationdate": schema.StringAttribute{
+						Optional:    true,
+						Description: "Date after which to mark the budget as consumed regardless of spend on it. A YYYY-MM-DD or RFC 3339 date, a relative expiration counting from the budget's issue date such as '+90d' (w, m and y count weeks, months and years), or 'end_of_fiscal_year'.",
+						Validators:  []validator.String{expirationValidator{}},
+					},
+					"dateissued": schema.StringAttribute{
+						Computed:    true,
+						Description: "Budget issue date, YYYY-MM-DD or RFC 3339 if the portal gives a time of day. Used in budget alerting emails.",
+					},
+					"dateactivated": schema.StringAttribute{
+						Computed:    true,
+						Description: "Budget activation date. Date on which the budget activate its billing account and tracking consumption. YYYY-MM-DD or RFC 3339 if the portal gives a time of day.",
+					},
+					"datesuspended": schema.StringAttribute{
+						Computed:    true,
+						Description: "Date on which the budget was deactivate and marked consumed. YYYY-MM-DD or RFC 3339 if the portal gives a time of day.",
+					},
+					"state": schema.StringAttribute{
+						Optional:    true,
+						Computed:    true,
+						Default:     stringdefault.StaticString("Future"),
+						Description: "Default: 'Future'. Valid values are 'Active' and 'Future'. WARNING! If set to 'Active', this budget will mark existing active budgets as consumed and set the GCP project's billing account to the specified billingaccountid!",
+					},
+					"recurring": schema.BoolAttribute{
+						Optional:    true,
+						Computed:    true,
+						Default:     booldefault.StaticBool(false),
+						Description: "Default: false. Whether the budget should be a recurring monthly budget or a standard budget.",
+					},
+				},
+			},
 		},
-	},
+	}
 }
 
+func (r *projectResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Not configured yet during validation.
+	if req.ProviderData == nil {
+		return
+	}
 
+	client, ok := req.ProviderData.(*Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data", fmt.Sprintf("Expected *Client, got %T.", req.ProviderData))
+		return
+	}
 
-var projectSchema = map[string]*schema.Schema{
-	"projectid": &schema.Schema{
-		Type:     schema.TypeString,
-		Required: true,
-		Description: "GCP Project ID",
-	},
-	"projectname": &schema.Schema{
-		Type:     schema.TypeString,
-		Optional: true,
-		Description: "Project name  as shown in the portal.",
-	},
-	"primarycontactemail": &schema.Schema{
-		Type:     schema.TypeString,
-		Optional: true,
-		Description: "The project primary contact email address.",
-	},
-	"billingcontactemail": &schema.Schema{
-		Type:     schema.TypeString,
-		Optional: true,
-		Description: "Primary billing contact email.",
-	},
-	"aftercredits": &schema.Schema{
-		Type:     schema.TypeString,
-		Default: "Suspend",
-		Optional: true,
-		Description: "Valid values: 'Bill' or 'Suspend'. Only set to bill if post-budget free spend is desired.",
-	},
-	"aftercreditsaccount": &schema.Schema {
-		Type: schema.TypeString,
-		Optional: true,
-		Description: "GCP billing account to use for post-credit consumption. Only applies if aftercredits is set to 'Suspend' ",
-	},
-	"aftercreditspo": &schema.Schema {
-		Type: schema.TypeString,
-		Optional: true,
-		Description: "Purchase Order for afterCredits consumption.",
-	},
-	"paidbillingaccount": &schema.Schema {
-		Type: schema.TypeString,
-		Optional: true,
-		Description: "The project GCP billing account ID. WARNING! This will change the project's billing account in GCP!",
-	},
-	"totalbudget": &schema.Schema{
-		Type:     schema.TypeString,
-		Computed: true,
-		Description: "Total budget dollar amount on the project.",
-	},
-	"recurringbudget": &schema.Schema {
-		Type: schema.TypeBool,
-		Default: false,
-		Optional: true,
-		Description: "Boolean. Whether project budgets should recur on a monthly basis.",
-	},
-	"departmentid": &schema.Schema{
-		Type:     schema.TypeString,
-		Required: true,
-		Description: "Department ID to for the project. If invalid or not given, the project will be placed into the 'Unaffiliated Projects' department. Department names and ID's can be seen in the group hierarchy data source, and a code example can be seen in the guides.",
-	},
-	"departmentname": &schema.Schema{
-		Type:     schema.TypeString,
-		Computed: true,
-		Description: "Department name that the project is under.",
-	},
-	"latestbudget": &schema.Schema {
-		Type: schema.TypeList,
-		Elem: budgetSchema,
-		Optional: true,
-		Description: "Most recently added budget. If given as a subblock, a new budget will be appended to the prjoect. See the budget schema for more details.",
-	},
+	r.client = client
 }
- 
 
-func resourceProject() *schema.Resource {
-	return &schema.Resource{
-		ReadContext: resourceProjectRead,
-		UpdateContext: resourceProjectCreateOrUpdate,
-		DeleteContext: resourceProjectDelete,
-		CreateContext: resourceProjectCreateOrUpdate, 
-		Schema:      projectSchema,
+// ModifyPlan keeps an unconfigured latestbudget currency the portal defaulted
+// while the budget is unchanged, and the computed attributes when only
+// on_destroy or timeouts change. When a budget is appended it marks
+// totalbudget unknown, and an unconfigured paidbillingaccount too if the
+// budget is active, as the portal switches it to the budget's account.
+func (r *projectResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		// Destroying.
+		return
+	}
+
+	var config, plan, state projectResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	fiscalYearStart := time.January
+	if r.client != nil {
+		fiscalYearStart = r.client.FiscalYearStart
+	}
+
+	budget := plan.LatestBudget
+	if budget != nil && state.LatestBudget != nil && config.LatestBudget != nil && config.LatestBudget.Currency.IsNull() {
+		// Terraform proposes the prior currency. It is only kept while it is the
+		// portal's default and the budget is unchanged; a currency removed from
+		// the configuration is due for a new budget in the portal's default.
+		configured, diags := req.Private.GetKey(ctx, currencyConfiguredKey)
+		resp.Diagnostics.Append(diags...)
+
+		unchanged := *budget
+		unchanged.Currency = state.LatestBudget.Currency
+		budget.Currency = state.LatestBudget.Currency
+		if string(configured) == "true" || budgetChanged(&unchanged, state.LatestBudget, fiscalYearStart) {
+			budget.Currency = types.StringUnknown()
+		}
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("latestbudget").AtName("currency"), budget.Currency)...)
+	}
+
+	if !req.State.Raw.IsNull() && !projectChanged(&plan, &state, fiscalYearStart) {
+		// Only on_destroy or timeouts changed, which Update saves without
+		// calling the portal, so the computed attributes stay as they are.
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("departmentname"), state.DepartmentName)...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("totalbudget"), state.TotalBudget)...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("totalbudget_currency"), state.TotalBudgetCurrency)...)
+		if state.LatestBudget != nil {
+			for name, value := range map[string]types.String{
+				"dateissued":    state.LatestBudget.DateIssued,
+				"dateactivated": state.LatestBudget.DateActivated,
+				"datesuspended": state.LatestBudget.DateSuspended,
+			} {
+				resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("latestbudget").AtName(name), value)...)
+			}
+		}
+
+		return
+	}
+
+	appending := budget != nil && (req.State.Raw.IsNull() || budgetChanged(budget, state.LatestBudget, fiscalYearStart))
+	if appending {
+		// The portal adds the new budget to the total.
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("totalbudget"), types.NumberUnknown())...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("totalbudget_currency"), types.StringUnknown())...)
+	}
+
+	activating := appending && budget.State.ValueString() == "Active"
+	if config.PaidBillingAccount.IsNull() && activating {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("paidbillingaccount"), types.StringUnknown())...)
 	}
 }
 
-func (c *Client) postProject(projectID string, postBody Project) (*Project, error) {
-	postBodyMarshaled, err := json.Marshal(postBody)
-	if err != nil {
-		return nil, err
+func (r *projectResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx, collector := withContractCollector(ctx)
+	defer func() {
+		resp.Diagnostics.Append(frameworkDiagnostics(collector.diagnostics())...)
+	}()
+
+	var plan projectResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	processedBody := strings.NewReader(string(postBodyMarshaled))
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/project/%s", c.HostURL, projectID), processedBody)
-	if err != nil {
-		return nil, err
+	timeout, diags := plan.Timeouts.Create(ctx, defaultProjectCreateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
-	
 
-	responseBody, err := c.doRequest(req, nil)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	defer func() {
+		resp.Diagnostics = timeoutDiagnostics(ctx, "create", timeout, resp.Diagnostics)
+	}()
 
-	// Unmarshal response JSON into a map data structure
-	responseBodyUnmarshal := &Project{}
-	err = json.Unmarshal(responseBody, &responseBodyUnmarshal)
-	if responseBodyUnmarshal == nil {
-		return nil, err
+	appendBudget := plan.LatestBudget != nil
+	currencyConfigured := appendBudget && !plan.LatestBudget.Currency.IsUnknown()
+
+	var posted postedExpiration
+	saved, diags := r.write(ctx, &plan, "Creating", appendBudget, &posted)
+	resp.Diagnostics.Append(diags...)
+	if !saved {
+		return
 	}
-	if err != nil {
-		return nil, err
+
+	// With errors, Terraform saves the project tainted.
+	if appendBudget {
+		resp.Diagnostics.Append(setBudgetPrivateState(ctx, resp.Private, currencyConfigured, posted)...)
 	}
 
-	return responseBodyUnmarshal, nil
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
 }
 
+func (r *projectResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx, collector := withContractCollector(ctx)
+	defer func() {
+		resp.Diagnostics.Append(frameworkDiagnostics(collector.diagnostics())...)
+	}()
 
-func resourceProjectCreateOrUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics { 
-	var diags diag.Diagnostics
+	var state projectResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	c := m.(*Client)
-	projectID := d.Get("projectid").(string)
-	projectStruct := Project {
-		ProjectID: projectID,
-		ProjectName: d.Get("projectname").(string),
-		PrimaryContactEmail: d.Get("primarycontactemail").(string),
-		BillingContactEmail: d.Get("billingcontactemail").(string),
-		AfterCredits: d.Get("aftercredits").(string),
-		AfterCreditsAccount: d.Get("aftercreditsaccount").(string),
-		AfterCreditsPO: d.Get("aftercreditspo").(string),
-		PaidBillingAccount: d.Get("paidbillingaccount").(string),
-		TotalBudget: d.Get("totalbudget").(string),
-		RecurringBudget: d.Get("recurringbudget").(bool),
-		DepartmentID: d.Get("departmentid").(string),
+	timeout, diags := state.Timeouts.Read(ctx, defaultProjectReadTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	response, err := c.postProject(projectID, projectStruct)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	defer func() {
+		resp.Diagnostics = timeoutDiagnostics(ctx, "read", timeout, resp.Diagnostics)
+	}()
 
-	if err != nil || response == nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error Creating Project",
-			Detail:   fmt.Sprintf("Project: %v", projectStruct),
-		})
-
-		return diag.FromErr(err)
-	}
-
-
-	// Latest allowance comes in as a list,
-	// but that's only so we can use the TypeList schema
-	// for the allowance.
-	allowanceList := d.Get("latestbudget").([]interface{})
-
-	if (len(allowanceList) > 1) {
-		// Undefined behavior for multiple allowances.
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error With latestbudget",
-			Detail:   fmt.Sprintf("Can't specify multiple budgets."),
-		})
-
-		return diag.FromErr(err)
-	} else if (len(allowanceList) == 1) {
-		allowanceObject := allowanceList[0].(map[string]interface{})
-		allowanceStruct := Allowance {
-			PONumber: allowanceObject["ponumber"].(string),
-			Grant: allowanceObject["grant"].(string),
-			Amount: allowanceObject["amount"].(float64),
-			BillingAccountID: allowanceObject["billingaccountid"].(string),
-			ExpirationDate: allowanceObject["expirationdate"].(string),
-			DateSuspended: allowanceObject["datesuspended"].(string),
-			DateActivated: allowanceObject["dateactivated"].(string),
-			State: allowanceObject["state"].(string),
-			Recurring: allowanceObject["recurring"].(bool),
-		}
-		err = c.postBudget(projectID, "project", allowanceStruct)
-	
-		if err != nil  {
-			diags = append(diags, diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error Creating Budget",
-				Detail:   fmt.Sprintf("Budget: %v", allowanceStruct),
-			})
-	
-			return diag.FromErr(err)
-		}
-	} 
+	posted, diags := getPostedExpiration(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
 
-	d.SetId(projectID)
+	found, diags := readProject(ctx, r.client, &state, posted)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	return diags
+	if !found {
+		// Deleted outside of Terraform. Let Terraform recreate it.
+		tflog.Warn(ctx, "Project not found, removing it from state", map[string]interface{}{"projectid": state.ID.ValueString()})
+		resp.State.RemoveResource(ctx)
+
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 }
 
+func (r *projectResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx, collector := withContractCollector(ctx)
+	defer func() {
+		resp.Diagnostics.Append(frameworkDiagnostics(collector.diagnostics())...)
+	}()
+
+	var plan, state projectResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
+	// on_destroy and timeouts only live in state.
+	if !projectChanged(&plan, &state, r.client.FiscalYearStart) {
+		resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
 
-func (c *Client) postBudget(entityID string, scope string, postBody Allowance) (error) {
-	postBodyMarshaled, err := json.Marshal(postBody)
-	if err != nil {
-		return err
+		return
 	}
 
-	processedBody := strings.NewReader(string(postBodyMarshaled))
-	req, err := http.NewRequest("POST", fmt.Sprintf("%s/api/%s/%s/add_budget", c.HostURL, scope, entityID), processedBody)
-	if err != nil {
-		return err
+	timeout, diags := plan.Timeouts.Update(ctx, defaultProjectUpdateTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
 	}
-	
 
-	_, err = c.doRequest(req, nil)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	defer func() {
+		resp.Diagnostics = timeoutDiagnostics(ctx, "update", timeout, resp.Diagnostics)
+	}()
 
-	if err != nil {
-		return err
+	// Only append a budget when the block changed,
+	// otherwise every project update would add a duplicate.
+	appendBudget := budgetChanged(plan.LatestBudget, state.LatestBudget, r.client.FiscalYearStart) && plan.LatestBudget != nil
+	currencyConfigured := appendBudget && !plan.LatestBudget.Currency.IsUnknown()
+
+	posted, diags := getPostedExpiration(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+
+	saved, diags := r.write(ctx, &plan, "Updating", appendBudget, posted)
+	resp.Diagnostics.Append(diags...)
+	if !saved {
+		return
 	}
 
-	return nil
+	if appendBudget {
+		resp.Diagnostics.Append(setBudgetPrivateState(ctx, resp.Private, currencyConfigured, *posted)...)
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
 }
 
+func (r *projectResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx, collector := withContractCollector(ctx)
+	defer func() {
+		resp.Diagnostics.Append(frameworkDiagnostics(collector.diagnostics())...)
+	}()
+
+	var state projectResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
+	timeout, diags := state.Timeouts.Delete(ctx, defaultProjectDeleteTimeout)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-func (c *Client) getProject(projectID string) (*Project, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/project/%s", c.HostURL, projectID), nil)
-	if err != nil {
-		return nil, err
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	defer func() {
+		resp.Diagnostics = timeoutDiagnostics(ctx, "delete", timeout, resp.Diagnostics)
+	}()
+
+	projectID := state.ID.ValueString()
+	onDestroy := state.OnDestroy.ValueString()
+
+	var err error
+	switch onDestroy {
+	case onDestroyAbandon:
+		tflog.Info(ctx, "Abandoning project, it stays in the portal", map[string]interface{}{"projectid": projectID})
+		return
+	case onDestroyUnassign:
+		err = unassignProject(ctx, r.client, projectID)
+	default:
+		err = r.client.deleteProject(ctx, projectID)
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		// Already gone.
+		return
 	}
-	
-	responseBody, err := c.doRequest(req, nil)
+
 	if err != nil {
-		return nil, err
+		summary := fmt.Sprintf("Error Deleting Project %s", projectID)
+		if onDestroy == onDestroyUnassign {
+			summary = fmt.Sprintf("Error Unassigning Project %s", projectID)
+		}
+		resp.Diagnostics.AddAttributeError(path.Root("projectid"), summary, errorDetail(err))
 	}
-	responseBodyUnmarshal := &Project{}
-	err = json.Unmarshal(responseBody, &responseBodyUnmarshal)
-	if responseBodyUnmarshal == nil {
-		return nil, err
+}
+
+// getExistingProject gets a project from the portal, or nil if it doesn't
+// exist. The portal answers either with a 404 or an empty project.
+func getExistingProject(ctx context.Context, c *Client, projectID string) (*Project, error) {
+	project, err := c.getProject(ctx, projectID)
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
+	if project.ProjectID == "" {
+		return nil, nil
+	}
 
-	return responseBodyUnmarshal, nil
+	return project, nil
 }
 
-func (c *Client) getLatestProjectBudget(projectID string) (*Allowance, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/api/project/%s/budgets", c.HostURL, projectID), nil)
+// unaffiliatedDepartmentName - Portal department of projects outside any other.
+const unaffiliatedDepartmentName = "Unaffiliated Projects"
+
+// unassignProject moves a project out of its department, into the portal's
+// 'Unaffiliated Projects'.
+func unassignProject(ctx context.Context, c *Client, projectID string) error {
+	project, err := getExistingProject(ctx, c, projectID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if project == nil {
+		return nil
 	}
-	
-	responseBody, err := c.doRequest(req, nil)
 
+	departmentID, err := unaffiliatedDepartmentID(ctx, c)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	responseBodyUnmarshal := []Allowance{}
-	err = json.Unmarshal(responseBody, &responseBodyUnmarshal)
-	if responseBodyUnmarshal == nil {
-		return nil, err
-	}
+	// Posting replaces the project, so the other fields are sent back as read.
+	project.DepartmentID = departmentID
+	project.DepartmentName = unaffiliatedDepartmentName
+	project.TotalBudget = nil
+	_, err = c.postProject(ctx, projectID, *project)
+
+	return err
+}
+
+// unaffiliatedDepartmentID looks up the ID of 'Unaffiliated Projects' in the
+// group hierarchy.
+func unaffiliatedDepartmentID(ctx context.Context, c *Client) (string, error) {
+	var departmentID string
+	err := c.listGroupHierarchy(ctx, func(group Group) error {
+		for _, department := range group.Departments {
+			if department.DepartmentName == unaffiliatedDepartmentName && departmentID == "" {
+				departmentID = department.DepartmentID
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("looking up the %q department: %w", unaffiliatedDepartmentName, err)
 	}
 
-	// Get the most recently configured budget object.
-	// Should be the last element in the JSON response.
-	latestBudgetObject := &Allowance{}
-	if len(responseBodyUnmarshal) > 0 {
-		latestBudgetObject = &responseBodyUnmarshal[len(responseBodyUnmarshal) - 1]
+	if departmentID == "" {
+		return "", fmt.Errorf("the group hierarchy has no %q department to move the project into. Set on_destroy to %q or %q instead",
+			unaffiliatedDepartmentName, onDestroyAbandon, onDestroyDelete)
 	}
-	return latestBudgetObject, nil
-}
 
-func resourceProjectRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	// Use this slice for detailed terraform diagnostics
-	var diags diag.Diagnostics
+	return departmentID, nil
+}
 
-	c := m.(*Client)
+// ImportState imports a project by its GCP project ID.
+func (r *projectResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("projectid"), req.ID)...)
+}
 
-	projectID := d.Get("projectid")
-	projectObject, err := c.getProject(projectID.(string))
+// write posts the planned project, and its latest budget if appendBudget,
+// then refreshes plan from the portal and waits for any billing account
+// switch. action is "Creating" or "Updating", for diagnostics. posted is the
+// latest budget's expiration, updated when a budget is appended. It returns
+// whether plan holds the posted project, to be saved to state even alongside
+// errors.
+func (r *projectResource) write(ctx context.Context, plan *projectResourceModel, action string, appendBudget bool, posted *postedExpiration) (bool, fwdiag.Diagnostics) {
+	var diags fwdiag.Diagnostics
+
+	projectID := plan.ProjectID.ValueString()
+	projectStruct := Project{
+		ProjectID:           projectID,
+		ProjectName:         plan.ProjectName.ValueString(),
+		PrimaryContactEmail: plan.PrimaryContactEmail.ValueString(),
+		BillingContactEmail: plan.BillingContactEmail.ValueString(),
+		AfterCredits:        plan.AfterCredits.ValueString(),
+		AfterCreditsAccount: plan.AfterCreditsAccount.ValueString(),
+		AfterCreditsPO:      plan.AfterCreditsPO.ValueString(),
+		PaidBillingAccount:  plan.PaidBillingAccount.ValueString(),
+		RecurringBudget:     plan.RecurringBudget.ValueBool(),
+		DepartmentID:        plan.DepartmentID.ValueString(),
+	}
 
-	if err != nil || projectObject == nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error Retrieving Project",
-			Detail:   fmt.Sprintf("Project: %v", projectObject),
-		})
+	response, err := r.client.postProject(ctx, projectID, projectStruct)
+	if err != nil || response == nil {
+		diags.AddAttributeError(path.Root("projectid"), fmt.Sprintf("Error %s Project %s", action, projectID), errorDetail(err))
 
-		return diag.FromErr(err)
+		return false, diags
 	}
 
-	d.SetId(projectID.(string))
-	d.Set("projectid", projectID.(string))
-	d.Set("projectname", projectObject.ProjectName)
-	d.Set("primarycontactemail", projectObject.PrimaryContactEmail)
-	d.Set("billingcontactemail", projectObject.BillingContactEmail)
-	d.Set("aftercredits", projectObject.AfterCredits)
-	d.Set("aftercreditsaccount", projectObject.AfterCreditsAccount)
-	d.Set("aftercreditspo", projectObject.AfterCreditsPO)
-	d.Set("paidbillingaccount", projectObject.PaidBillingAccount)
-	d.Set("totalbudget", projectObject.TotalBudget)
-	d.Set("recurringbudget", projectObject.RecurringBudget)
-	d.Set("departmentid", projectObject.DepartmentID)
-	d.Set("departmentname", projectObject.DepartmentName)
+	// What the portal shows once it has switched the GCP billing account.
+	billing := billingState{PaidBillingAccount: plan.PaidBillingAccount.ValueString()}
+	waitForBilling := !plan.PaidBillingAccount.IsNull() && !plan.PaidBillingAccount.IsUnknown()
 
+	if appendBudget && plan.LatestBudget != nil {
+		budget := plan.LatestBudget
+		amount, err := MoneyFromBigFloat(budget.Amount.ValueBigFloat())
+		if err != nil {
+			diags.AddAttributeError(path.Root("latestbudget").AtName("amount"), fmt.Sprintf("Error Creating Budget for Project %s", projectID), err.Error())
+
+			return false, diags
+		}
 
-	if projectObject.ProjectID != "" { // Handling the case where no such project exists
-		budgetObject, err := c.getLatestProjectBudget(projectID.(string))
-		d.Set("latestbudget", budgetObject)
+		// Relative expirations count from today, the new budget's issue date.
+		expirationDate, err := resolveExpiration(budget.ExpirationDate.ValueString(), time.Now().UTC(), r.client.FiscalYearStart)
 		if err != nil {
-			diags = append(diags, diag.Diagnostic{
-				Severity: diag.Error,
-				Summary:  "Error Retrieving Latest Budget",
-				Detail:   fmt.Sprintf("Project: %v", projectObject),
-			})
-			return diag.FromErr(err)
+			diags.AddAttributeError(path.Root("latestbudget").AtName("expirationdate"), fmt.Sprintf("Error Creating Budget for Project %s", projectID), err.Error())
+
+			return false, diags
+		}
+
+		allowanceStruct := Allowance{
+			PONumber:         budget.PONumber.ValueString(),
+			Grant:            budget.Grant.ValueString(),
+			Amount:           amount,
+			Currency:         budget.Currency.ValueString(),
+			BillingAccountID: budget.BillingAccountID.ValueString(),
+			ExpirationDate:   expirationDate,
+			State:            budget.State.ValueString(),
+			Recurring:        budget.Recurring.ValueBool(),
+		}
+
+		err = r.client.postBudget(ctx, "project", projectID, allowanceStruct)
+		if err != nil {
+			diags.AddAttributeError(path.Root("latestbudget"), fmt.Sprintf("Error Creating Budget for Project %s", projectID), errorDetail(err))
+
+			return false, diags
+		}
+		*posted = postedExpiration{Value: budget.ExpirationDate.ValueString(), Date: expirationDate.String()}
+
+		// An active budget switches the project to its billing account.
+		if allowanceStruct.State == "Active" {
+			billing = billingState{PaidBillingAccount: allowanceStruct.BillingAccountID, LatestBudgetState: "Active"}
+			waitForBilling = true
 		}
 	}
-	
 
-	return diags
+	plan.ID = types.StringValue(projectID)
+	departmentID := plan.DepartmentID
+
+	found, readDiags := readProject(ctx, r.client, plan, posted)
+	diags.Append(readDiags...)
+	if diags.HasError() {
+		return false, diags
+	}
+
+	if !found {
+		diags.AddAttributeError(path.Root("projectid"), fmt.Sprintf("Error Retrieving Project %s", projectID), "The portal accepted the project but doesn't return it.")
+
+		return false, diags
+	}
+
+	// plan now holds the project as posted. Should waiting fail, it is saved
+	// regardless so the next apply doesn't post the project and budget again.
+	if waitForBilling {
+		diags.Append(waitForBillingSwitch(ctx, r.client, projectID, billing)...)
+		if diags.HasError() {
+			return true, diags
+		}
+
+		_, readDiags := readProject(ctx, r.client, plan, posted)
+		diags.Append(readDiags...)
+		if diags.HasError() {
+			return true, diags
+		}
+	}
+
+	// The portal files projects with an unknown department under
+	// 'Unaffiliated Projects' instead of rejecting them. Keep the configured
+	// ID so the apply is consistent; the next refresh shows the move.
+	plan.DepartmentID = departmentID
+
+	return true, diags
 }
 
+// readProject refreshes model from the portal, returning false if the
+// project doesn't exist. Blocks can't be computed, so latestbudget is only
+// read if the model already has one, or on import when only the ID is known.
+// posted is the latest budget's expiration from private state, if any.
+func readProject(ctx context.Context, c *Client, model *projectResourceModel, posted *postedExpiration) (bool, fwdiag.Diagnostics) {
+	var diags fwdiag.Diagnostics
+
+	projectID := model.ID.ValueString()
+	importing := model.DepartmentID.IsNull()
 
-func (c *Client) deleteProject(projectID string) (error) {
-	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/api/project/%s", c.HostURL, projectID), nil)
+	projectObject, err := getExistingProject(ctx, c, projectID)
 	if err != nil {
-		return err
+		diags.AddAttributeError(path.Root("projectid"), fmt.Sprintf("Error Retrieving Project %s", projectID), errorDetail(err))
+
+		return false, diags
+	}
+	if projectObject == nil {
+		return false, diags
+	}
+
+	model.ProjectID = types.StringValue(projectID)
+	model.ProjectName = optionalString(projectObject.ProjectName)
+	model.PrimaryContactEmail = optionalString(projectObject.PrimaryContactEmail)
+	model.BillingContactEmail = optionalString(projectObject.BillingContactEmail)
+	model.AfterCredits = types.StringValue(projectObject.AfterCredits)
+	model.AfterCreditsAccount = optionalString(projectObject.AfterCreditsAccount)
+	model.AfterCreditsPO = optionalString(projectObject.AfterCreditsPO)
+	model.PaidBillingAccount = optionalString(projectObject.PaidBillingAccount)
+	model.TotalBudget = types.NumberNull()
+	if projectObject.TotalBudget != nil {
+		model.TotalBudget = moneyValue(*projectObject.TotalBudget)
+	}
+	model.RecurringBudget = types.BoolValue(projectObject.RecurringBudget)
+	model.DepartmentID = types.StringValue(projectObject.DepartmentID)
+	model.DepartmentName = types.StringValue(projectObject.DepartmentName)
+	if model.OnDestroy.IsNull() {
+		// Imported, or state from before on_destroy.
+		model.OnDestroy = types.StringValue(onDestroyDelete)
 	}
-	
-	_, err = c.doRequest(req, nil)
+
+	// The portal returns budgets oldest first.
+	var budgetObject *Allowance
+	currencies := map[string]bool{}
+	err = c.listProjectBudgets(ctx, projectID, func(budget Allowance) error {
+		budgetObject = &budget
+		currencies[budget.Currency] = true
+		return nil
+	})
 	if err != nil {
-		return err
+		diags.AddAttributeError(path.Root("latestbudget"), fmt.Sprintf("Error Retrieving Budgets for Project %s", projectID), errorDetail(err))
+
+		return false, diags
+	}
+
+	// totalbudget adds up the amounts of all budgets, whatever their currency.
+	model.TotalBudgetCurrency = types.StringNull()
+	switch {
+	case len(currencies) == 1:
+		model.TotalBudgetCurrency = optionalString(budgetObject.Currency)
+	case len(currencies) > 1:
+		diags.AddAttributeWarning(path.Root("totalbudget"), "Budgets in Different Currencies",
+			fmt.Sprintf("The budgets of project %s are in %s. The portal adds up their amounts regardless, so totalbudget isn't an amount in any one currency and totalbudget_currency is null.",
+				projectID, strings.Join(currencyList(currencies), ", ")))
+	}
+
+	if model.LatestBudget == nil && !importing {
+		return true, diags
+	}
+
+	// Keep the configured expirationdate while it stands for the portal's,
+	// so other formats or relative expirations don't show as drift.
+	prior := model.LatestBudget
+	model.LatestBudget = flattenLatestBudget(budgetObject)
+	if latest := model.LatestBudget; prior != nil && latest != nil {
+		value, date := prior.ExpirationDate.ValueString(), latest.ExpirationDate.ValueString()
+		if sameExpiration(value, date, latest.DateIssued.ValueString(), c.FiscalYearStart) || posted.matches(value, date) {
+			latest.ExpirationDate = prior.ExpirationDate
+		}
+	}
+
+	return true, diags
+}
+
+// getPostedExpiration returns the latest budget's expiration from private
+// state, empty for state written before it was recorded.
+func getPostedExpiration(ctx context.Context, private interface {
+	GetKey(context.Context, string) ([]byte, fwdiag.Diagnostics)
+}) (*postedExpiration, fwdiag.Diagnostics) {
+	posted := &postedExpiration{}
+
+	raw, diags := private.GetKey(ctx, postedExpirationKey)
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, posted); err != nil {
+			diags.AddError("Unable to Read Private State", fmt.Sprintf("Decoding %s: %s", postedExpirationKey, err))
+		}
 	}
 
-	return nil
+	return posted, diags
 }
 
-func resourceProjectDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics { 
-	var diags diag.Diagnostics
-	c := m.(*Client)
-	projectID := d.Get("projectid")
-	err := c.deleteProject(projectID.(string))
+// setBudgetPrivateState records the appended budget's currency setting and
+// expiration in private state.
+func setBudgetPrivateState(ctx context.Context, private interface {
+	SetKey(context.Context, string, []byte) fwdiag.Diagnostics
+}, currencyConfigured bool, posted postedExpiration) fwdiag.Diagnostics {
+	diags := private.SetKey(ctx, currencyConfiguredKey, []byte(strconv.FormatBool(currencyConfigured)))
+
+	raw, err := json.Marshal(posted)
 	if err != nil {
-		diags = append(diags, diag.Diagnostic{
-			Severity: diag.Error,
-			Summary:  "Error Deleting Project",
-			Detail:   fmt.Sprintf("Error: %v", err),
-		})
+		diags.AddError("Unable to Write Private State", fmt.Sprintf("Encoding %s: %s", postedExpirationKey, err))
 
-		return diag.FromErr(err)
+		return diags
 	}
-	
+	diags.Append(private.SetKey(ctx, postedExpirationKey, raw)...)
+
 	return diags
 }
+
+// currencyList returns the currency codes in currencies sorted, for messages.
+func currencyList(currencies map[string]bool) []string {
+	var codes []string
+	for code := range currencies {
+		if code == "" {
+			code = "no currency"
+		}
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	return codes
+}
+
+func (c *Client) getLatestProjectBudget(ctx context.Context, projectID string) (*Allowance, error) {
+	// Get the most recently configured budget object.
+	// Should be the last element in the JSON response, so only
+	// the latest item is kept while walking the pages.
+	var latestBudgetObject *Allowance
+
+	err := c.listProjectBudgets(ctx, projectID, func(budgetObject Allowance) error {
+		latestBudgetObject = &budgetObject
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return latestBudgetObject, nil
+}
+
+// Convert a budget into the latestbudget block. A project without budgets has none.
+func flattenLatestBudget(budget *Allowance) *budgetResourceModel {
+	if budget == nil {
+		return nil
+	}
+
+	return &budgetResourceModel{
+		PONumber:         optionalString(budget.PONumber),
+		Grant:            optionalString(budget.Grant),
+		Amount:           moneyValue(budget.Amount),
+		Currency:         optionalString(budget.Currency),
+		BillingAccountID: types.StringValue(budget.BillingAccountID),
+		ExpirationDate:   optionalString(budget.ExpirationDate.String()),
+		DateIssued:       types.StringValue(budget.DateIssued.String()),
+		DateActivated:    types.StringValue(budget.DateActivated.String()),
+		DateSuspended:    types.StringValue(budget.DateSuspended.String()),
+		State:            types.StringValue(budget.State),
+		Recurring:        types.BoolValue(budget.Recurring),
+	}
+}
+
+// projectChanged reports whether plan changes the project in the portal,
+// rather than only on_destroy or timeouts.
+func projectChanged(plan, state *projectResourceModel, fiscalYearStart time.Month) bool {
+	return !plan.ProjectID.Equal(state.ProjectID) ||
+		!plan.ProjectName.Equal(state.ProjectName) ||
+		!plan.PrimaryContactEmail.Equal(state.PrimaryContactEmail) ||
+		!plan.BillingContactEmail.Equal(state.BillingContactEmail) ||
+		!plan.AfterCredits.Equal(state.AfterCredits) ||
+		!plan.AfterCreditsAccount.Equal(state.AfterCreditsAccount) ||
+		!plan.AfterCreditsPO.Equal(state.AfterCreditsPO) ||
+		!plan.PaidBillingAccount.Equal(state.PaidBillingAccount) ||
+		!plan.RecurringBudget.Equal(state.RecurringBudget) ||
+		!plan.DepartmentID.Equal(state.DepartmentID) ||
+		budgetChanged(plan.LatestBudget, state.LatestBudget, fiscalYearStart)
+}
+
+// budgetChanged reports whether the configurable fields of the latestbudget
+// block differ, ignoring the computed dates and equivalent expirations.
+func budgetChanged(plan, state *budgetResourceModel, fiscalYearStart time.Month) bool {
+	if plan == nil || state == nil {
+		return plan != state
+	}
+
+	return !plan.PONumber.Equal(state.PONumber) ||
+		!plan.Grant.Equal(state.Grant) ||
+		!plan.Amount.Equal(state.Amount) ||
+		!plan.Currency.Equal(state.Currency) ||
+		!plan.BillingAccountID.Equal(state.BillingAccountID) ||
+		!sameExpiration(plan.ExpirationDate.ValueString(), state.ExpirationDate.ValueString(), state.DateIssued.ValueString(), fiscalYearStart) ||
+		!plan.State.Equal(state.State) ||
+		!plan.Recurring.Equal(state.Recurring)
+}
+
+// optionalString maps the empty strings the portal returns for unset
+// fields to null, matching optional attributes left out of the configuration.
+func optionalString(s string) types.String {
+	if s == "" {
+		return types.StringNull()
+	}
+
+	return types.StringValue(s)
+}
+
+// moneyValue converts an amount. The portal omits amounts it has none for.
+func moneyValue(m Money) types.Number {
+	if m.IsNull() {
+		return types.NumberNull()
+	}
+
+	return types.NumberValue(m.BigFloat())
+}
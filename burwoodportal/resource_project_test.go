@@ -0,0 +1,805 @@
+package burwoodportal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"burwoodportal/burwoodportal/portaltest"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccProject_basic(t *testing.T) {
+	portal := newTestAccPortal(t)
+	projectID := testAccProjectID()
+	resourceName := "burwoodportal_projects.test"
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckProjectDestroy(t, portal),
+		Steps: []resource.TestStep{
+			// Create with an active budget.
+			{
+				Config: testAccProjectConfig(portal, testAccProject{ProjectID: projectID, PaidBillingAccount: portal.BillingAccountID, Budget: &testAccBudget{Amount: "100", State: "Active"}}),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckProjectExists(t, portal, resourceName),
+					resource.TestCheckResourceAttr(resourceName, "id", projectID),
+					resource.TestCheckResourceAttr(resourceName, "projectname", "Acceptance Test"),
+					resource.TestCheckResourceAttr(resourceName, "departmentid", portal.DepartmentIDs[0]),
+					resource.TestCheckResourceAttrSet(resourceName, "departmentname"),
+					resource.TestCheckResourceAttr(resourceName, "paidbillingaccount", portal.BillingAccountID),
+					resource.TestCheckResourceAttr(resourceName, "totalbudget", "100"),
+					resource.TestCheckResourceAttr(resourceName, "latestbudget.amount", "100"),
+					resource.TestCheckResourceAttr(resourceName, "latestbudget.state", "Active"),
+					resource.TestCheckResourceAttrSet(resourceName, "latestbudget.dateissued"),
+				),
+			},
+			// Update project fields without appending another budget.
+			{
+				Config: testAccProjectConfig(portal, testAccProject{
+					ProjectID:          projectID,
+					ProjectName:        "Acceptance Test Renamed",
+					DepartmentID:       portal.DepartmentIDs[1],
+					PaidBillingAccount: portal.BillingAccountID,
+					Budget:             &testAccBudget{Amount: "100", State: "Active"},
+				}),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "projectname", "Acceptance Test Renamed"),
+					resource.TestCheckResourceAttr(resourceName, "departmentid", portal.DepartmentIDs[1]),
+					resource.TestCheckResourceAttr(resourceName, "totalbudget", "100"),
+				),
+			},
+			// Changing latestbudget appends a budget; amounts stay exact decimals.
+			{
+				Config: testAccProjectConfig(portal, testAccProject{
+					ProjectID:          projectID,
+					ProjectName:        "Acceptance Test Renamed",
+					DepartmentID:       portal.DepartmentIDs[1],
+					PaidBillingAccount: portal.BillingAccountID,
+					Budget:             &testAccBudget{Amount: "50.25", State: "Future"},
+				}),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "totalbudget", "150.25"),
+					resource.TestCheckResourceAttr(resourceName, "latestbudget.amount", "50.25"),
+					resource.TestCheckResourceAttr(resourceName, "latestbudget.state", "Future"),
+					resource.TestCheckResourceAttr(resourceName, "paidbillingaccount", portal.BillingAccountID),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccProject_disappears(t *testing.T) {
+	portal := newTestAccPortal(t)
+	projectID := testAccProjectID()
+	resourceName := "burwoodportal_projects.test"
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckProjectDestroy(t, portal),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProjectConfig(portal, testAccProject{ProjectID: projectID, PaidBillingAccount: portal.BillingAccountID, Budget: &testAccBudget{Amount: "100", State: "Active"}}),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckProjectExists(t, portal, resourceName),
+					testAccCheckProjectDisappears(t, portal, projectID),
+				),
+				// Refresh drops the deleted project and the plan recreates it.
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestAccProject_unknownDepartment(t *testing.T) {
+	portal := newTestAccPortal(t)
+	projectID := testAccProjectID()
+	resourceName := "burwoodportal_projects.test"
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckProjectDestroy(t, portal),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProjectConfig(portal, testAccProject{ProjectID: projectID, DepartmentID: "tf-acc-missing", PaidBillingAccount: portal.BillingAccountID}),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckProjectExists(t, portal, resourceName),
+					resource.TestCheckResourceAttr(resourceName, "departmentid", "tf-acc-missing"),
+					resource.TestCheckResourceAttr(resourceName, "departmentname", "Unaffiliated Projects"),
+				),
+				// Refresh reads the unaffiliated department and the plan moves the project back.
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+}
+
+func TestAccProject_currency(t *testing.T) {
+	portal := newTestAccPortal(t)
+	projectID := testAccProjectID()
+	resourceName := "burwoodportal_projects.test"
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckProjectDestroy(t, portal),
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccProjectConfig(portal, testAccProject{ProjectID: projectID, Budget: &testAccBudget{Amount: "100", Currency: "eur"}}),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Invalid Currency`),
+			},
+			{
+				Config:      testAccProjectConfig(portal, testAccProject{ProjectID: projectID, Budget: &testAccBudget{Amount: "100", Currency: "ABC"}}),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`not an ISO 4217 currency code`),
+			},
+			// Without a currency the budget is in the portal's.
+			{
+				Config: testAccProjectConfig(portal, testAccProject{ProjectID: projectID, Budget: &testAccBudget{Amount: "100"}}),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "latestbudget.currency", portaltest.DefaultCurrency),
+					resource.TestCheckResourceAttr(resourceName, "totalbudget_currency", portaltest.DefaultCurrency),
+				),
+			},
+			// The total adds up budgets in different currencies.
+			{
+				Config: testAccProjectConfig(portal, testAccProject{ProjectID: projectID, Budget: &testAccBudget{Amount: "200", Currency: "EUR"}}),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "latestbudget.amount", "200"),
+					resource.TestCheckResourceAttr(resourceName, "latestbudget.currency", "EUR"),
+					resource.TestCheckNoResourceAttr(resourceName, "totalbudget_currency"),
+					testAccCheckProjectBudgets(t, portal, projectID, 2, ""),
+				),
+			},
+			// Removing the currency posts the budget again in the portal's.
+			{
+				Config: testAccProjectConfig(portal, testAccProject{ProjectID: projectID, Budget: &testAccBudget{Amount: "200"}}),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "latestbudget.currency", portaltest.DefaultCurrency),
+					testAccCheckProjectBudgets(t, portal, projectID, 3, ""),
+				),
+			},
+		},
+	})
+}
+
+func TestAccProject_expiration(t *testing.T) {
+	portal := newTestAccPortal(t)
+	portal.providerAttrs["fiscal_year_start"] = "7"
+	projectID := testAccProjectID()
+	resourceName := "burwoodportal_projects.test"
+
+	today := time.Now().UTC()
+	in90Days, _ := resolveExpiration("+90d", today, time.July)
+	fiscalYearEnd, _ := resolveExpiration(endOfFiscalYear, today, time.July)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckProjectDestroy(t, portal),
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccProjectConfig(portal, testAccProject{ProjectID: projectID, Budget: &testAccBudget{Amount: "100", ExpirationDate: "in a while"}}),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Invalid Expiration Date`),
+			},
+			// The relative expiration stays in state, the portal gets the date.
+			{
+				Config: testAccProjectConfig(portal, testAccProject{ProjectID: projectID, Budget: &testAccBudget{Amount: "100", ExpirationDate: "+90d"}}),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "latestbudget.expirationdate", "+90d"),
+					testAccCheckProjectBudgets(t, portal, projectID, 1, in90Days.String()),
+				),
+			},
+			// An equivalent representation doesn't append a budget.
+			{
+				Config: testAccProjectConfig(portal, testAccProject{ProjectID: projectID, Budget: &testAccBudget{Amount: "100", ExpirationDate: in90Days.Format(time.RFC3339)}}),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "latestbudget.expirationdate", in90Days.Format(time.RFC3339)),
+					testAccCheckProjectBudgets(t, portal, projectID, 1, in90Days.String()),
+				),
+			},
+			{
+				Config: testAccProjectConfig(portal, testAccProject{ProjectID: projectID, Budget: &testAccBudget{Amount: "100", ExpirationDate: "end_of_fiscal_year"}}),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "latestbudget.expirationdate", "end_of_fiscal_year"),
+					testAccCheckProjectBudgets(t, portal, projectID, 2, fiscalYearEnd.String()),
+				),
+			},
+		},
+	})
+}
+
+func TestAccProject_timeouts(t *testing.T) {
+	portal := newTestAccPortal(t)
+	if portal.Server == nil {
+		t.Skip("injecting latency needs the portaltest fake")
+	}
+	projectID := testAccProjectID()
+	config := testAccProjectConfig(portal, testAccProject{
+		ProjectID:          projectID,
+		PaidBillingAccount: portal.BillingAccountID,
+		Timeouts:           map[string]string{"read": "1s"},
+		Budget:             &testAccBudget{Amount: "100", State: "Active"},
+	})
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckProjectDestroy(t, portal),
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				Check:  resource.TestCheckResourceAttr("burwoodportal_projects.test", "timeouts.read", "1s"),
+			},
+			// The refresh outlasts the read timeout.
+			{
+				PreConfig: func() {
+					portal.Server.InjectFault(portaltest.Fault{Method: http.MethodGet, Path: "/api/project/", Latency: 3 * time.Second, Times: 1})
+				},
+				Config:      config,
+				ExpectError: regexp.MustCompile(`The read operation did not finish within its 1s timeout`),
+			},
+		},
+	})
+}
+
+func TestAccProject_billingSwitch(t *testing.T) {
+	portal := newTestAccPortal(t)
+	if portal.Server != nil {
+		portal.Server.BillingSwitchDelay = 2 * time.Second
+	}
+	projectID := testAccProjectID()
+	resourceName := "burwoodportal_projects.test"
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckProjectDestroy(t, portal),
+		Steps: []resource.TestStep{
+			// Reads right after activation would still show the previous billing.
+			{
+				Config: testAccProjectConfig(portal, testAccActivationProject(projectID, "Active", "5m")),
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "paidbillingaccount", portal.BillingAccountID),
+					resource.TestCheckResourceAttr(resourceName, "latestbudget.state", "Active"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccProject_billingSwitchTimeout(t *testing.T) {
+	portal := newTestAccPortal(t)
+	if portal.Server == nil {
+		t.Skip("delaying the billing account switch needs the portaltest fake")
+	}
+	portal.Server.BillingSwitchDelay = time.Hour
+	projectID := testAccProjectID()
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckProjectDestroy(t, portal),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccProjectConfig(portal, testAccActivationProject(projectID, "Future", "2s")),
+			},
+			{
+				Config:      testAccProjectConfig(portal, testAccActivationProject(projectID, "Active", "2s")),
+				ExpectError: regexp.MustCompile(`Timed Out Waiting for Billing Account Switch`),
+			},
+		},
+	})
+}
+
+// A project whose billing account switch times out on create is saved
+// tainted, so the next apply doesn't post it and its budget again.
+func TestAccProject_billingSwitchTimeoutCreate(t *testing.T) {
+	portal := newTestAccPortal(t)
+	if portal.Server == nil {
+		t.Skip("delaying the billing account switch needs the portaltest fake")
+	}
+	portal.Server.BillingSwitchDelay = time.Hour
+	projectID := testAccProjectID()
+	resourceName := "burwoodportal_projects.test"
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		CheckDestroy:             testAccCheckProjectDestroy(t, portal),
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccProjectConfig(portal, testAccActivationProject(projectID, "Active", "2s")),
+				ExpectError: regexp.MustCompile(`Timed Out Waiting for Billing Account Switch`),
+			},
+			{
+				RefreshState:       true,
+				ExpectNonEmptyPlan: true,
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "id", projectID),
+					testAccCheckProjectTainted(resourceName),
+					testAccCheckProjectBudgets(t, portal, projectID, 1, ""),
+				),
+			},
+		},
+	})
+}
+
+func TestAccProject_onDestroy(t *testing.T) {
+	portal := newTestAccPortal(t)
+	resourceName := "burwoodportal_projects.test"
+
+	for _, tc := range []struct {
+		onDestroy string
+		check     func(project *Project) error
+	}{
+		{
+			onDestroy: "unassign",
+			check: func(project *Project) error {
+				if project.DepartmentName != "Unaffiliated Projects" {
+					return fmt.Errorf("project %s is in department %q, expected 'Unaffiliated Projects'", project.ProjectID, project.DepartmentName)
+				}
+				if project.ProjectName != "Acceptance Test" || project.PaidBillingAccount != portal.BillingAccountID {
+					return fmt.Errorf("unassigning changed project %s: %+v", project.ProjectID, project)
+				}
+				return nil
+			},
+		},
+		{
+			onDestroy: "abandon",
+			check: func(project *Project) error {
+				if project.DepartmentID != portal.DepartmentIDs[0] {
+					return fmt.Errorf("project %s moved to department %q", project.ProjectID, project.DepartmentID)
+				}
+				return nil
+			},
+		},
+	} {
+		t.Run(tc.onDestroy, func(t *testing.T) {
+			projectID := testAccProjectID()
+			t.Cleanup(func() { portal.Client(t).deleteProject(context.Background(), projectID) })
+
+			config := testAccProjectConfig(portal, testAccProject{
+				ProjectID:          projectID,
+				PaidBillingAccount: portal.BillingAccountID,
+				OnDestroy:          tc.onDestroy,
+				Timeouts:           map[string]string{"delete": "5m"},
+				Budget:             &testAccBudget{Amount: "100", State: "Active"},
+			})
+
+			var requests int
+			resource.Test(t, resource.TestCase{
+				ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+				CheckDestroy:             testAccCheckProjectKept(t, portal, projectID, tc.check),
+				Steps: []resource.TestStep{
+					{
+						Config: testAccProjectConfig(portal, testAccProject{
+							ProjectID:          projectID,
+							PaidBillingAccount: portal.BillingAccountID,
+							Budget:             &testAccBudget{Amount: "100", State: "Active"},
+						}),
+						Check: resource.TestCheckResourceAttr(resourceName, "on_destroy", "delete"),
+					},
+					// on_destroy and timeouts only change state.
+					{
+						PreConfig: func() {
+							if portal.Server != nil {
+								requests = len(portal.Server.Requests())
+							}
+						},
+						Config: config,
+						Check: resource.ComposeTestCheckFunc(
+							resource.TestCheckResourceAttr(resourceName, "on_destroy", tc.onDestroy),
+							resource.TestCheckResourceAttr(resourceName, "timeouts.delete", "5m"),
+							testAccCheckNoProjectWrites(portal, &requests),
+						),
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestAccProject_onDestroyInvalid(t *testing.T) {
+	portal := newTestAccPortal(t)
+	config := testAccProjectConfig(portal, testAccProject{
+		ProjectID: testAccProjectID(),
+		OnDestroy: "archive",
+		Budget:    &testAccBudget{Amount: "100", State: "Active"},
+	})
+
+	resource.Test(t, resource.TestCase{
+		ProtoV5ProviderFactories: testAccProtoV5ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      config,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`value must be one of`),
+			},
+		},
+	})
+}
+
+func TestUnassignProject(t *testing.T) {
+	server := portaltest.NewServer()
+	defer server.Close()
+	server.AddGroup(portaltest.Group{
+		GroupName:   "Test",
+		GroupID:     "group",
+		Departments: []portaltest.Department{{DepartmentName: "Research", DepartmentID: "research"}},
+	})
+	c := newTestClient(t, server)
+	ctx := context.Background()
+
+	projectID := testAccPrefix + "unassign"
+	if _, err := c.postProject(ctx, projectID, Project{ProjectName: "Test", DepartmentID: "research", PaidBillingAccount: "billing-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := unassignProject(ctx, c, projectID); err != nil {
+		t.Fatal(err)
+	}
+
+	project, _ := server.Project(projectID)
+	if project.DepartmentID != portaltest.UnaffiliatedDepartmentID || project.ProjectName != "Test" || project.PaidBillingAccount != "billing-1" {
+		t.Errorf("unexpected project after unassigning: %+v", project)
+	}
+	// The department is posted by ID rather than left to the portal's fallback.
+	requests := server.Requests()
+	if last := requests[len(requests)-1]; last.Method != http.MethodPost || !strings.Contains(last.Body, `"departmentid":"`+portaltest.UnaffiliatedDepartmentID+`"`) {
+		t.Errorf("unassigned with %s %s", last.Method, last.Body)
+	}
+
+	if err := unassignProject(ctx, c, testAccPrefix+"missing"); err != nil {
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+			t.Errorf("missing project: %s", err)
+		}
+	}
+}
+
+func TestUnassignProject_noUnaffiliatedDepartment(t *testing.T) {
+	server := portaltest.NewServer()
+	defer server.Close()
+	c := newTestClient(t, server)
+	ctx := context.Background()
+
+	// A portal whose unaffiliated group has no 'Unaffiliated Projects'.
+	_, err := c.postGroups(ctx, []Group{{
+		GroupName:   "Unaffiliated",
+		GroupID:     portaltest.UnaffiliatedGroupID,
+		Departments: []Department{{DepartmentName: "Research", DepartmentID: "research"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	projectID := testAccPrefix + "unassign"
+	if _, err := c.postProject(ctx, projectID, Project{ProjectName: "Test", DepartmentID: "research"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = unassignProject(ctx, c, projectID)
+	if err == nil || !strings.Contains(err.Error(), `no "Unaffiliated Projects" department`) {
+		t.Fatalf("got %v, want an error naming the missing department", err)
+	}
+	if project, _ := server.Project(projectID); project.DepartmentID != "research" {
+		t.Errorf("project moved to department %q", project.DepartmentID)
+	}
+}
+
+func TestReadProject(t *testing.T) {
+	projectID := testAccPrefix + "cassette"
+	c := newCassetteClient(t, func(portal *testAccPortal, c *Client) {
+		ctx := context.Background()
+		if _, err := c.postProject(ctx, projectID, Project{ProjectName: "Cassette Test", DepartmentID: portal.DepartmentIDs[0]}); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { c.deleteProject(ctx, projectID) })
+		if err := c.postBudget(ctx, "project", projectID, Allowance{Amount: testMoney("100"), BillingAccountID: portal.BillingAccountID, State: "Active"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	// As on import, only the ID is known.
+	model := projectResourceModel{ID: types.StringValue(projectID)}
+	found, diags := readProject(context.Background(), c, &model, nil)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if !found {
+		t.Fatal("project not found")
+	}
+
+	if model.ProjectID.ValueString() != projectID || model.ProjectName.ValueString() != "Cassette Test" {
+		t.Errorf("unexpected project %s %q", model.ProjectID, model.ProjectName)
+	}
+	if model.TotalBudget.ValueBigFloat().String() != "100" || model.TotalBudgetCurrency.ValueString() != portaltest.DefaultCurrency {
+		t.Errorf("totalbudget = %s %s, want 100 %s", model.TotalBudget, model.TotalBudgetCurrency, portaltest.DefaultCurrency)
+	}
+	for name, value := range map[string]types.String{"departmentid": model.DepartmentID, "departmentname": model.DepartmentName, "paidbillingaccount": model.PaidBillingAccount} {
+		if value.ValueString() == "" {
+			t.Errorf("%s not set", name)
+		}
+	}
+
+	budget := model.LatestBudget
+	if budget == nil {
+		t.Fatal("latestbudget not set")
+	}
+	if budget.Amount.ValueBigFloat().String() != "100" || budget.State.ValueString() != "Active" || budget.DateIssued.ValueString() == "" {
+		t.Errorf("unexpected latestbudget %+v", budget)
+	}
+}
+
+// The portal issues budgets on its own day, which need not be the day a
+// relative expiration was resolved on when the budget was posted.
+func TestReadProject_postedExpiration(t *testing.T) {
+	server := portaltest.NewServer()
+	defer server.Close()
+	c := newTestClient(t, server)
+	ctx := context.Background()
+
+	projectID := testAccPrefix + "posted"
+	if _, err := c.postProject(ctx, projectID, Project{ProjectName: "Test"}); err != nil {
+		t.Fatal(err)
+	}
+	yesterday := time.Now().UTC().AddDate(0, 0, -1)
+	postedDate, err := resolveExpiration("+90d", yesterday, time.January)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.postBudget(ctx, "project", projectID, Allowance{Amount: testMoney("100"), BillingAccountID: "billing-1", ExpirationDate: postedDate}); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range map[string]struct {
+		posted *postedExpiration
+		want   string
+	}{
+		"posted on another day": {posted: &postedExpiration{Value: "+90d", Date: postedDate.String()}, want: "+90d"},
+		"not recorded":          {want: postedDate.String()},
+		"other value posted":    {posted: &postedExpiration{Value: "+30d", Date: postedDate.String()}, want: postedDate.String()},
+	} {
+		model := projectResourceModel{
+			ID:           types.StringValue(projectID),
+			DepartmentID: types.StringValue(portaltest.UnaffiliatedDepartmentID),
+			LatestBudget: &budgetResourceModel{ExpirationDate: types.StringValue("+90d")},
+		}
+		if _, diags := readProject(ctx, c, &model, tc.posted); diags.HasError() {
+			t.Fatalf("%s: unexpected diagnostics: %v", name, diags)
+		}
+		if got := model.LatestBudget.ExpirationDate.ValueString(); got != tc.want {
+			t.Errorf("%s: expirationdate = %q, want %q", name, got, tc.want)
+		}
+	}
+}
+
+func TestReadProject_notFound(t *testing.T) {
+	c := newCassetteClient(t, func(*testAccPortal, *Client) {})
+
+	model := projectResourceModel{ID: types.StringValue(testAccPrefix + "cassette-missing")}
+	found, diags := readProject(context.Background(), c, &model, nil)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if found {
+		t.Error("missing project reported as found")
+	}
+}
+
+// testAccProject - Arguments of the burwoodportal_projects.test resource
+// testAccProjectConfig builds. Empty fields are left out; the project name
+// defaults to "Acceptance Test" and the department to the portal's first.
+type testAccProject struct {
+	ProjectID          string
+	ProjectName        string
+	DepartmentID       string
+	PaidBillingAccount string
+	OnDestroy          string
+	Timeouts           map[string]string
+	Budget             *testAccBudget
+}
+
+// testAccBudget - Arguments of the latestbudget block, whose billing
+// account is the portal's. Amount is an HCL number, e.g. "50.25".
+type testAccBudget struct {
+	Amount         string
+	Currency       string
+	ExpirationDate string
+	State          string
+}
+
+func testAccProjectConfig(portal *testAccPortal, project testAccProject) string {
+	if project.ProjectName == "" {
+		project.ProjectName = "Acceptance Test"
+	}
+	if project.DepartmentID == "" {
+		project.DepartmentID = portal.DepartmentIDs[0]
+	}
+
+	var b strings.Builder
+	writeArg := func(indent, name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s%s = %q\n", indent, name, value)
+		}
+	}
+
+	b.WriteString(portal.ProviderConfig())
+	b.WriteString("\nresource \"burwoodportal_projects\" \"test\" {\n")
+	writeArg("  ", "projectid", project.ProjectID)
+	writeArg("  ", "projectname", project.ProjectName)
+	writeArg("  ", "departmentid", project.DepartmentID)
+	writeArg("  ", "paidbillingaccount", project.PaidBillingAccount)
+	writeArg("  ", "on_destroy", project.OnDestroy)
+
+	if len(project.Timeouts) > 0 {
+		operations := make([]string, 0, len(project.Timeouts))
+		for operation := range project.Timeouts {
+			operations = append(operations, operation)
+		}
+		sort.Strings(operations)
+
+		b.WriteString("\n  timeouts {\n")
+		for _, operation := range operations {
+			writeArg("    ", operation, project.Timeouts[operation])
+		}
+		b.WriteString("  }\n")
+	}
+
+	if budget := project.Budget; budget != nil {
+		b.WriteString("\n  latestbudget {\n")
+		fmt.Fprintf(&b, "    amount = %s\n", budget.Amount)
+		writeArg("    ", "billingaccountid", portal.BillingAccountID)
+		writeArg("    ", "currency", budget.Currency)
+		writeArg("    ", "expirationdate", budget.ExpirationDate)
+		writeArg("    ", "state", budget.State)
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// testAccActivationProject leaves out paidbillingaccount, so only
+// activating the budget switches billing accounts.
+func testAccActivationProject(projectID, state, timeout string) testAccProject {
+	return testAccProject{
+		ProjectID: projectID,
+		Timeouts:  map[string]string{"create": timeout, "update": timeout},
+		Budget:    &testAccBudget{Amount: "100", State: state},
+	}
+}
+
+// testAccCheckProjectBudgets checks the number of budgets on the project
+// and the expiration date of the latest as the portal has it.
+func testAccCheckProjectBudgets(t *testing.T, portal *testAccPortal, projectID string, count int, expirationDate string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		var budgets []Allowance
+		err := portal.Client(t).listProjectBudgets(context.Background(), projectID, func(budget Allowance) error {
+			budgets = append(budgets, budget)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(budgets) != count {
+			return fmt.Errorf("project %s has %d budgets, expected %d", projectID, len(budgets), count)
+		}
+		if latest := budgets[len(budgets)-1]; latest.ExpirationDate.String() != expirationDate {
+			return fmt.Errorf("latest budget of project %s expires %q, expected %q", projectID, latest.ExpirationDate, expirationDate)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckProjectTainted(resourceName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("%s not found in state", resourceName)
+		}
+		if !rs.Primary.Tainted {
+			return fmt.Errorf("%s is not tainted", resourceName)
+		}
+
+		return nil
+	}
+}
+
+func testAccCheckProjectExists(t *testing.T, portal *testAccPortal, resourceName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("resource %s not found in state", resourceName)
+		}
+
+		project, err := portal.Client(t).getProject(context.Background(), rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+		if project.ProjectID != rs.Primary.ID {
+			return fmt.Errorf("project %s not found in the portal", rs.Primary.ID)
+		}
+
+		return nil
+	}
+}
+
+// testAccCheckNoProjectWrites checks that the portaltest fake received no
+// project or budget writes after the first requests it recorded.
+func testAccCheckNoProjectWrites(portal *testAccPortal, requests *int) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if portal.Server == nil {
+			return nil
+		}
+
+		for _, request := range portal.Server.Requests()[*requests:] {
+			if request.Method != http.MethodGet && (strings.HasPrefix(request.Path, "/api/project/") || strings.HasSuffix(request.Path, "/add_budget")) {
+				return fmt.Errorf("unexpected %s %s", request.Method, request.Path)
+			}
+		}
+
+		return nil
+	}
+}
+
+// testAccCheckProjectDisappears deletes the project behind Terraform's back.
+func testAccCheckProjectDisappears(t *testing.T, portal *testAccPortal, projectID string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		return portal.Client(t).deleteProject(context.Background(), projectID)
+	}
+}
+
+// testAccCheckProjectKept checks a project destroyed with on_destroy set to
+// keep it is still in the portal.
+func testAccCheckProjectKept(t *testing.T, portal *testAccPortal, projectID string, check func(*Project) error) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		project, err := portal.Client(t).getProject(context.Background(), projectID)
+		if err != nil {
+			return err
+		}
+		if project.ProjectID != projectID {
+			return fmt.Errorf("project %s was deleted", projectID)
+		}
+
+		return check(project)
+	}
+}
+
+func testAccCheckProjectDestroy(t *testing.T, portal *testAccPortal) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		c := portal.Client(t)
+
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "burwoodportal_projects" {
+				continue
+			}
+
+			project, err := c.getProject(context.Background(), rs.Primary.ID)
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if project.ProjectID != "" {
+				return fmt.Errorf("project %s still exists", rs.Primary.ID)
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,100 @@
+package burwoodportal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+// UpgradeState upgrades burwoodportal_projects state written by earlier releases.
+// Version 0 is the plugin SDK resource's state.
+func (r *projectResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	return map[int64]resource.StateUpgrader{
+		0: {StateUpgrader: upgradeProjectStateV0},
+	}
+}
+
+// Steps from version 0 to the current version, applied in order to the raw state.
+var projectStateV0Upgrades = []func(state map[string]interface{}) error{
+	upgradeProjectTotalBudgetV0,
+	upgradeProjectLatestBudgetV0,
+}
+
+func upgradeProjectStateV0(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	if req.RawState == nil || req.RawState.JSON == nil {
+		resp.Diagnostics.AddError("Unable to Upgrade Project State", "The version 0 state has no JSON data.")
+		return
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(req.RawState.JSON))
+	decoder.UseNumber()
+
+	var state map[string]interface{}
+	if err := decoder.Decode(&state); err != nil {
+		resp.Diagnostics.AddError("Unable to Upgrade Project State", fmt.Sprintf("Decoding the version 0 state: %s", err))
+		return
+	}
+
+	for _, upgrade := range projectStateV0Upgrades {
+		if err := upgrade(state); err != nil {
+			resp.Diagnostics.AddError("Unable to Upgrade Project State", err.Error())
+			return
+		}
+	}
+
+	upgraded, err := json.Marshal(state)
+	if err != nil {
+		resp.Diagnostics.AddError("Unable to Upgrade Project State", fmt.Sprintf("Encoding the upgraded state: %s", err))
+		return
+	}
+
+	resp.DynamicValue = &tfprotov6.DynamicValue{JSON: upgraded}
+}
+
+// upgradeProjectTotalBudgetV0 converts totalbudget from a string to a number.
+// Version 0 stored the portal's value as-is, and "" when it had none.
+func upgradeProjectTotalBudgetV0(state map[string]interface{}) error {
+	switch totalBudget := state["totalbudget"].(type) {
+	case nil, json.Number:
+	case string:
+		if totalBudget == "" {
+			state["totalbudget"] = nil
+			return nil
+		}
+
+		number := json.Number(totalBudget)
+		if _, err := number.Float64(); err != nil {
+			return fmt.Errorf("totalbudget %q is not a number", totalBudget)
+		}
+		state["totalbudget"] = number
+	default:
+		return fmt.Errorf("unexpected totalbudget %v in version 0 state", totalBudget)
+	}
+
+	return nil
+}
+
+// upgradeProjectLatestBudgetV0 converts latestbudget from a list of at most
+// one budget to a single object.
+func upgradeProjectLatestBudgetV0(state map[string]interface{}) error {
+	switch latestBudget := state["latestbudget"].(type) {
+	case nil, map[string]interface{}:
+	case []interface{}:
+		switch len(latestBudget) {
+		case 0:
+			state["latestbudget"] = nil
+		case 1:
+			state["latestbudget"] = latestBudget[0]
+		default:
+			return fmt.Errorf("version 0 state has %d latestbudget blocks, expected at most one", len(latestBudget))
+		}
+	default:
+		return fmt.Errorf("unexpected latestbudget %v in version 0 state", latestBudget)
+	}
+
+	return nil
+}
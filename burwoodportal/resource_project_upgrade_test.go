@@ -0,0 +1,84 @@
+package burwoodportal
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestUpgradeProjectTotalBudgetV0(t *testing.T) {
+	cases := map[string]struct {
+		totalBudget interface{}
+		want        interface{}
+		wantErr     bool
+	}{
+		"number string":  {totalBudget: "100", want: json.Number("100")},
+		"decimal string": {totalBudget: "99.95", want: json.Number("99.95")},
+		"empty string":   {totalBudget: "", want: nil},
+		"null":           {totalBudget: nil, want: nil},
+		"already number": {totalBudget: json.Number("42"), want: json.Number("42")},
+		"not a number":   {totalBudget: "lots", wantErr: true},
+		"unexpected":     {totalBudget: true, wantErr: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			state := map[string]interface{}{"totalbudget": tc.totalBudget}
+
+			err := upgradeProjectTotalBudgetV0(state)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got totalbudget %#v", state["totalbudget"])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(state["totalbudget"], tc.want) {
+				t.Errorf("totalbudget = %#v, want %#v", state["totalbudget"], tc.want)
+			}
+		})
+	}
+}
+
+func TestUpgradeProjectLatestBudgetV0(t *testing.T) {
+	budget := map[string]interface{}{
+		"amount":           json.Number("100"),
+		"billingaccountid": "000000-000000-000000",
+		"state":            "Active",
+	}
+
+	cases := map[string]struct {
+		latestBudget interface{}
+		want         interface{}
+		wantErr      bool
+	}{
+		"one block":      {latestBudget: []interface{}{budget}, want: budget},
+		"no blocks":      {latestBudget: []interface{}{}, want: nil},
+		"null":           {latestBudget: nil, want: nil},
+		"already object": {latestBudget: budget, want: budget},
+		"two blocks":     {latestBudget: []interface{}{budget, budget}, wantErr: true},
+		"unexpected":     {latestBudget: "budget", wantErr: true},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			state := map[string]interface{}{"latestbudget": tc.latestBudget}
+
+			err := upgradeProjectLatestBudgetV0(state)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got latestbudget %#v", state["latestbudget"])
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(state["latestbudget"], tc.want) {
+				t.Errorf("latestbudget = %#v, want %#v", state["latestbudget"], tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package burwoodportal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	fwdiag "github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+)
+
+// billingState - Billing of a project as the portal shows it.
+type billingState struct {
+	PaidBillingAccount string
+	// LatestBudgetState is only compared if set, i.e. after activating a budget.
+	LatestBudgetState string
+}
+
+func (b billingState) String() string {
+	if b.LatestBudgetState == "" {
+		return fmt.Sprintf("paidbillingaccount %q", b.PaidBillingAccount)
+	}
+
+	return fmt.Sprintf("paidbillingaccount %q with the latest budget %q", b.PaidBillingAccount, b.LatestBudgetState)
+}
+
+const (
+	billingSwitchPending = "pending"
+	billingSwitchDone    = "done"
+)
+
+// waitForBillingSwitch polls the project until the portal shows target. The
+// portal switches a project's GCP billing account asynchronously after an
+// active budget is added or paidbillingaccount changes, so reads right after
+// the request can still show the previous account. ctx bounds the wait.
+func waitForBillingSwitch(ctx context.Context, c *Client, projectID string, target billingState) fwdiag.Diagnostics {
+	var diags fwdiag.Diagnostics
+	var last billingState
+
+	timeout := defaultProjectUpdateTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		// The context ends the wait, Timeout is only a backstop.
+		timeout = time.Until(deadline) + time.Minute
+	}
+
+	conf := &retry.StateChangeConf{
+		Pending:    []string{billingSwitchPending},
+		Target:     []string{billingSwitchDone},
+		Timeout:    timeout,
+		MinTimeout: time.Second,
+		Refresh: func() (interface{}, string, error) {
+			project, err := c.getProject(ctx, projectID)
+			if err != nil {
+				return nil, "", err
+			}
+			last = billingState{PaidBillingAccount: project.PaidBillingAccount}
+
+			if target.LatestBudgetState != "" {
+				budget, err := c.getLatestProjectBudget(ctx, projectID)
+				if err != nil {
+					return nil, "", err
+				}
+				if budget != nil {
+					last.LatestBudgetState = budget.State
+				}
+			}
+
+			if last != target {
+				return project, billingSwitchPending, nil
+			}
+
+			return project, billingSwitchDone, nil
+		},
+	}
+
+	tflog.Debug(ctx, "Waiting for billing account switch", map[string]interface{}{
+		"projectid": projectID,
+		"target":    target.String(),
+	})
+
+	_, err := conf.WaitForStateContext(ctx)
+
+	var timeoutErr *retry.TimeoutError
+	switch {
+	case err == nil:
+	case errors.Is(err, context.DeadlineExceeded) || errors.As(err, &timeoutErr):
+		diags.AddAttributeError(path.Root("paidbillingaccount"), "Timed Out Waiting for Billing Account Switch",
+			fmt.Sprintf("Project %s still shows %s, expected %s. The portal switches GCP billing accounts asynchronously and may still complete the switch. "+
+				"The project is saved to state as the portal shows it, tainted if this apply created it; once the switch completes, run terraform untaint on it rather than let the next apply replace it.",
+				projectID, last, target))
+	default:
+		diags.AddAttributeError(path.Root("paidbillingaccount"), fmt.Sprintf("Error Waiting for Billing Account Switch of Project %s", projectID), errorDetail(err))
+	}
+
+	return diags
+}
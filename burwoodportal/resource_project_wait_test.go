@@ -0,0 +1,84 @@
+package burwoodportal
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"burwoodportal/burwoodportal/portaltest"
+)
+
+func TestWaitForBillingSwitch(t *testing.T) {
+	server := portaltest.NewServer()
+	defer server.Close()
+	c := newTestClient(t, server)
+	ctx := context.Background()
+
+	projectID := testAccPrefix + "switch"
+	if _, err := c.postProject(ctx, projectID, Project{ProjectName: "Test", PaidBillingAccount: "billing-1"}); err != nil {
+		t.Fatal(err)
+	}
+	server.BillingSwitchDelay = time.Second
+	if err := c.postBudget(ctx, "project", projectID, Allowance{Amount: testMoney("100"), BillingAccountID: "billing-2", State: "Active"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The portal still shows the previous account right after the request.
+	if project, _ := server.Project(projectID); project.PaidBillingAccount != "billing-1" {
+		t.Fatalf("billing account switched to %q without a delay", project.PaidBillingAccount)
+	}
+
+	target := billingState{PaidBillingAccount: "billing-2", LatestBudgetState: "Active"}
+	if diags := waitForBillingSwitch(ctx, c, projectID, target); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if project, _ := server.Project(projectID); project.PaidBillingAccount != "billing-2" {
+		t.Errorf("waited until paidbillingaccount %q, want billing-2", project.PaidBillingAccount)
+	}
+}
+
+func TestWaitForBillingSwitch_timeout(t *testing.T) {
+	server := portaltest.NewServer()
+	defer server.Close()
+	c := newTestClient(t, server)
+
+	projectID := testAccPrefix + "switch"
+	if _, err := c.postProject(context.Background(), projectID, Project{ProjectName: "Test", PaidBillingAccount: "billing-1"}); err != nil {
+		t.Fatal(err)
+	}
+	server.BillingSwitchDelay = time.Hour
+	if _, err := c.postProject(context.Background(), projectID, Project{ProjectName: "Test", PaidBillingAccount: "billing-2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	diags := waitForBillingSwitch(ctx, c, projectID, billingState{PaidBillingAccount: "billing-2"})
+	if diags.ErrorsCount() != 1 || diags[0].Summary() != "Timed Out Waiting for Billing Account Switch" {
+		t.Fatalf("got diagnostics %v, want a single timeout error", diags)
+	}
+	if detail := diags[0].Detail(); !strings.Contains(detail, `still shows paidbillingaccount "billing-1", expected paidbillingaccount "billing-2"`) {
+		t.Errorf("timeout error doesn't name the billing accounts: %s", detail)
+	}
+}
+
+func TestWaitForBillingSwitch_error(t *testing.T) {
+	server := portaltest.NewServer()
+	defer server.Close()
+	c := newTestClient(t, server)
+	ctx := context.Background()
+
+	projectID := testAccPrefix + "switch"
+	if _, err := c.postProject(ctx, projectID, Project{ProjectName: "Test"}); err != nil {
+		t.Fatal(err)
+	}
+	server.InjectFault(portaltest.Fault{Method: http.MethodGet, Path: "/api/project/", Status: http.StatusBadRequest, Body: "broken"})
+
+	diags := waitForBillingSwitch(ctx, c, projectID, billingState{PaidBillingAccount: "billing-2"})
+	if diags.ErrorsCount() != 1 || !strings.HasPrefix(diags[0].Summary(), "Error Waiting for Billing Account Switch") {
+		t.Fatalf("got diagnostics %v, want a single error", diags)
+	}
+}
@@ -0,0 +1,181 @@
+package burwoodportal
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"burwoodportal/burwoodportal/portaltest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestMain(m *testing.M) {
+	resource.TestMain(m)
+}
+
+// Sweepers delete what interrupted acceptance test runs left in a sandbox portal:
+//
+//	PORTAL_HOST=https://sandbox.example.com go test ./burwoodportal -v -sweep=sandbox
+//
+// Only projects, groups and departments starting with testAccPrefix are removed.
+// The sweep region is not used; the portal has none.
+func init() {
+	resource.AddTestSweepers("burwoodportal_projects", &resource.Sweeper{
+		Name: "burwoodportal_projects",
+		F: func(region string) error {
+			c, err := sweeperClient()
+			if err != nil {
+				return err
+			}
+			return sweepProjects(context.Background(), c, testAccPrefix)
+		},
+	})
+
+	resource.AddTestSweepers("burwoodportal_hierarchy", &resource.Sweeper{
+		Name:         "burwoodportal_hierarchy",
+		Dependencies: []string{"burwoodportal_projects"},
+		F: func(region string) error {
+			c, err := sweeperClient()
+			if err != nil {
+				return err
+			}
+			return sweepHierarchy(context.Background(), c, testAccPrefix)
+		},
+	})
+}
+
+// sweeperClient returns a client for the sandbox in PORTAL_HOST, with credentials
+// from the provider's environment variables. The host is required so a sweep
+// never defaults to the production portal.
+func sweeperClient() (*Client, error) {
+	host := os.Getenv("PORTAL_HOST")
+	if host == "" {
+		return nil, fmt.Errorf("PORTAL_HOST must be set to the sandbox portal to sweep")
+	}
+
+	provider := Provider()
+	if diags := provider.Configure(context.Background(), terraform.NewResourceConfigRaw(map[string]interface{}{"host": host})); diags.HasError() {
+		return nil, fmt.Errorf("configuring provider: %v", diags)
+	}
+
+	return provider.Meta().(*Client), nil
+}
+
+// sweepProjects deletes every project in the hierarchy whose ID starts with prefix.
+func sweepProjects(ctx context.Context, c *Client, prefix string) error {
+	projectIDs := []string{}
+	err := c.listGroupHierarchy(ctx, func(group Group) error {
+		for _, department := range group.Departments {
+			for _, project := range department.Projects {
+				if strings.HasPrefix(project.ProjectID, prefix) {
+					projectIDs = append(projectIDs, project.ProjectID)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("listing projects: %w", err)
+	}
+
+	for _, projectID := range projectIDs {
+		log.Printf("[INFO] Deleting project %s", projectID)
+		if err := c.deleteProject(ctx, projectID); err != nil {
+			return fmt.Errorf("deleting project %s: %w", projectID, err)
+		}
+	}
+
+	return nil
+}
+
+// sweepHierarchy posts the hierarchy without the groups and departments whose
+// IDs start with prefix. Their remaining projects move to 'Unaffiliated Projects'.
+func sweepHierarchy(ctx context.Context, c *Client, prefix string) error {
+	groups := []Group{}
+	swept := false
+	err := c.listGroupHierarchy(ctx, func(group Group) error {
+		if strings.HasPrefix(group.GroupID, prefix) {
+			log.Printf("[INFO] Deleting group %s", group.GroupID)
+			swept = true
+			return nil
+		}
+
+		departments := []Department{}
+		for _, department := range group.Departments {
+			if strings.HasPrefix(department.DepartmentID, prefix) {
+				log.Printf("[INFO] Deleting department %s", department.DepartmentID)
+				swept = true
+				continue
+			}
+			departments = append(departments, department)
+		}
+		group.Departments = departments
+		groups = append(groups, group)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("listing hierarchy: %w", err)
+	}
+
+	if !swept {
+		return nil
+	}
+
+	if _, err := c.postGroups(ctx, groups); err != nil {
+		return fmt.Errorf("updating hierarchy: %w", err)
+	}
+
+	return nil
+}
+
+func TestSweepers(t *testing.T) {
+	server := portaltest.NewServer()
+	defer server.Close()
+	c := newTestClient(t, server)
+	ctx := context.Background()
+
+	server.AddGroup(portaltest.Group{GroupName: "Kept", GroupID: "kept", Departments: []portaltest.Department{
+		{DepartmentName: "Kept", DepartmentID: "kept"},
+		{DepartmentName: "Swept", DepartmentID: "tf-sweep-department"},
+	}})
+	server.AddGroup(portaltest.Group{GroupName: "Swept", GroupID: "tf-sweep-group", Departments: []portaltest.Department{
+		{DepartmentName: "Swept", DepartmentID: "tf-sweep-group-department"},
+	}})
+	server.PutProject(portaltest.Project{ProjectID: "kept-project", DepartmentID: "kept"})
+	server.PutProject(portaltest.Project{ProjectID: "tf-sweep-project", DepartmentID: "kept"})
+	server.PutProject(portaltest.Project{ProjectID: "kept-in-swept-department", DepartmentID: "tf-sweep-department"})
+
+	if err := sweepProjects(ctx, c, "tf-sweep-"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sweepHierarchy(ctx, c, "tf-sweep-"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(server.Projects(), ","); got != "kept-in-swept-department,kept-project" {
+		t.Errorf("projects after sweep: %s", got)
+	}
+	if project, _ := server.Project("kept-in-swept-department"); project.DepartmentID != portaltest.UnaffiliatedDepartmentID {
+		t.Errorf("project of swept department moved to %q, want the unaffiliated department", project.DepartmentID)
+	}
+
+	ids := []string{}
+	err := c.listGroupHierarchy(ctx, func(group Group) error {
+		ids = append(ids, group.GroupID)
+		for _, department := range group.Departments {
+			ids = append(ids, department.DepartmentID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(ids, ","); got != "unaffiliated,unaffiliated,kept,kept" {
+		t.Errorf("hierarchy after sweep: %s", got)
+	}
+}
@@ -0,0 +1,154 @@
+package burwoodportal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultRequestTimeout - Timeout for a single API request when none is configured
+const DefaultRequestTimeout = 10 * time.Second
+
+// tlsVersions maps the min_tls_version provider setting to crypto/tls constants.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// TransportConfig - HTTP settings for talking to the portal API
+type TransportConfig struct {
+	// Timeout for a single request, including reading the response body.
+	Timeout time.Duration
+	// ProxyURL overrides the HTTP_PROXY/HTTPS_PROXY/NO_PROXY environment variables.
+	ProxyURL string
+	// CABundle is a path to, or the PEM content of, extra CA certificates to trust.
+	CABundle string
+	// MinTLSVersion is one of "1.0", "1.1", "1.2" or "1.3".
+	MinTLSVersion string
+	// InsecureSkipVerify disables TLS certificate verification.
+	InsecureSkipVerify bool
+	// ClientCertificate is presented to the portal for mutual TLS, see LoadClientCertificate.
+	ClientCertificate *tls.Certificate
+	// UserAgent is sent with every request, including OAuth2 token requests.
+	UserAgent string
+}
+
+// userAgentTransport sets the User-Agent header on every request.
+type userAgentTransport struct {
+	next      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+
+	return t.next.RoundTrip(req)
+}
+
+// NewHTTPClient builds an HTTP client from the transport config.
+func NewHTTPClient(config TransportConfig) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if config.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: config.InsecureSkipVerify,
+	}
+
+	if config.MinTLSVersion != "" {
+		version, ok := tlsVersions[config.MinTLSVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported minimum TLS version %q", config.MinTLSVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if config.CABundle != "" {
+		pem, err := readPEM(config.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA bundle: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA bundle contains no valid PEM certificates")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.ClientCertificate != nil {
+		tlsConfig.Certificates = []tls.Certificate{*config.ClientCertificate}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DefaultRequestTimeout
+	}
+
+	var roundTripper http.RoundTripper = transport
+	if config.UserAgent != "" {
+		roundTripper = &userAgentTransport{next: transport, userAgent: config.UserAgent}
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: roundTripper,
+	}, nil
+}
+
+// LoadClientCertificate loads a certificate/key pair for mutual TLS.
+// Each value is either a file path or PEM content.
+func LoadClientCertificate(certificate, key string) (*tls.Certificate, error) {
+	certificatePEM, err := readPEM(certificate)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read client certificate: %w", err)
+	}
+
+	keyPEM, err := readPEM(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read client key: %w", err)
+	}
+
+	pair, err := tls.X509KeyPair(certificatePEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("client certificate and key are not a valid pair: %w", err)
+	}
+
+	return &pair, nil
+}
+
+// readPEM returns value itself if it holds PEM content,
+// otherwise reads the file value points to.
+func readPEM(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+
+	path, err := expandHome(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.ReadFile(path)
+}
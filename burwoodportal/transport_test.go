@@ -0,0 +1,226 @@
+package burwoodportal
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClient_minTLSVersion(t *testing.T) {
+	cases := map[string]uint16{
+		"":    tls.VersionTLS12,
+		"1.0": tls.VersionTLS10,
+		"1.3": tls.VersionTLS13,
+	}
+
+	for version, want := range cases {
+		httpClient, err := NewHTTPClient(TransportConfig{MinTLSVersion: version})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := httpClient.Transport.(*http.Transport).TLSClientConfig.MinVersion; got != want {
+			t.Errorf("min_tls_version %q: MinVersion = %x, want %x", version, got, want)
+		}
+	}
+
+	if _, err := NewHTTPClient(TransportConfig{MinTLSVersion: "1.4"}); err == nil {
+		t.Error("min_tls_version 1.4: expected an error")
+	}
+
+	// The handshake fails against a server that can't speak the minimum version.
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	httpClient, err := NewHTTPClient(TransportConfig{MinTLSVersion: "1.3", CABundle: testServerCAPEM(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := httpClient.Get(server.URL); err == nil {
+		t.Error("TLS 1.2 server: expected the handshake to fail with min_tls_version 1.3")
+	}
+}
+
+func TestNewHTTPClient_proxy(t *testing.T) {
+	httpClient, err := NewHTTPClient(TransportConfig{ProxyURL: "http://proxy.example.com:3128"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://api.bcs.burwood.com/api/project/x", nil)
+	proxyURL, err := httpClient.Transport.(*http.Transport).Proxy(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proxyURL == nil || proxyURL.String() != "http://proxy.example.com:3128" {
+		t.Errorf("requests sent through proxy %v, want http://proxy.example.com:3128", proxyURL)
+	}
+
+	// Requests really go through the proxy.
+	proxied := false
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.Host == "portal.invalid"
+	}))
+	defer proxy.Close()
+
+	httpClient, err = NewHTTPClient(TransportConfig{ProxyURL: proxy.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := httpClient.Get("http://portal.invalid/api/group_hierarchy"); err != nil {
+		t.Fatal(err)
+	}
+	if !proxied {
+		t.Error("request not sent through the proxy")
+	}
+
+	if _, err := NewHTTPClient(TransportConfig{ProxyURL: "http://[::1"}); err == nil {
+		t.Error("invalid proxy URL: expected an error")
+	}
+}
+
+func TestNewHTTPClient_caBundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caPEM := testServerCAPEM(server)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte(caPEM), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The test server's certificate is signed by a CA only the bundle holds.
+	httpClient, err := NewHTTPClient(TransportConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := httpClient.Get(server.URL); err == nil {
+		t.Error("without ca_bundle: expected an unknown authority error")
+	}
+
+	for name, bundle := range map[string]string{"PEM content": caPEM, "file": caFile} {
+		httpClient, err := NewHTTPClient(TransportConfig{CABundle: bundle})
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if _, err := httpClient.Get(server.URL); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+	}
+
+	if _, err := NewHTTPClient(TransportConfig{CABundle: "-----BEGIN CERTIFICATE-----\nnot a certificate\n-----END CERTIFICATE-----\n"}); err == nil {
+		t.Error("invalid ca_bundle: expected an error")
+	}
+	if _, err := NewHTTPClient(TransportConfig{CABundle: filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("missing ca_bundle file: expected an error")
+	}
+}
+
+// testServerCAPEM returns the PEM certificate a TLS test server is signed with.
+func testServerCAPEM(server *httptest.Server) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+}
+
+func TestLoadClientCertificate(t *testing.T) {
+	certificatePEM, keyPEM := testClientCertificate(t)
+	_, otherKeyPEM := testClientCertificate(t)
+
+	dir := t.TempDir()
+	certificateFile, keyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certificateFile, []byte(certificatePEM), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, []byte(keyPEM), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadClientCertificate(certificatePEM, keyPEM); err != nil {
+		t.Errorf("PEM content: %s", err)
+	}
+	if _, err := LoadClientCertificate(certificateFile, keyFile); err != nil {
+		t.Errorf("files: %s", err)
+	}
+
+	_, err := LoadClientCertificate(certificatePEM, otherKeyPEM)
+	if err == nil || !strings.Contains(err.Error(), "not a valid pair") {
+		t.Errorf("key of another certificate: got %v, want a pair mismatch error", err)
+	}
+	if _, err := LoadClientCertificate(filepath.Join(dir, "missing.crt"), keyFile); err == nil {
+		t.Error("missing certificate file: expected an error")
+	}
+}
+
+func TestNewHTTPClient_clientCertificate(t *testing.T) {
+	certificatePEM, keyPEM := testClientCertificate(t)
+	certificate, err := LoadClientCertificate(certificatePEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AppendCertsFromPEM([]byte(certificatePEM))
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	httpClient, err := NewHTTPClient(TransportConfig{CABundle: testServerCAPEM(server), ClientCertificate: certificate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := httpClient.Get(server.URL); err != nil {
+		t.Errorf("with client certificate: %s", err)
+	}
+
+	httpClient, err = NewHTTPClient(TransportConfig{CABundle: testServerCAPEM(server)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := httpClient.Get(server.URL); err == nil {
+		t.Error("without client certificate: expected the handshake to fail")
+	}
+}
+
+// testClientCertificate returns a new self-signed client certificate and its key, PEM encoded.
+func testClientCertificate(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "terraform"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
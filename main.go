@@ -4,26 +4,32 @@ import (
 	"burwoodportal/burwoodportal"
 	"context"
 	"flag"
-	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov5/tf5server"
 	"log"
 )
 
+// Set by goreleaser at build time.
+var version string = "dev"
+
 func main() {
 	var debugMode bool
 
 	flag.BoolVar(&debugMode, "debug", false, "set to true to run the provider with support for debuggers like delve")
 	flag.Parse()
 
-	opts := &plugin.ServeOpts{ProviderFunc: burwoodportal.Provider}
+	// The plugin SDK and plugin framework providers are served as one.
+	providerServer, err := burwoodportal.ProviderServer(context.Background(), version)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
 
+	var serveOpts []tf5server.ServeOpt
 	if debugMode {
-		err := plugin.Debug(context.Background(), "burwood.com/portal/burwoodportal", opts)
-		if err != nil {
-			log.Print("Error with debugger")
-			log.Fatal(err.Error())
-		}
-		return
+		serveOpts = append(serveOpts, tf5server.WithManagedDebug())
 	}
 
-	plugin.Serve(opts)
+	err = tf5server.Serve("burwood.com/portal/burwoodportal", providerServer, serveOpts...)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
 }
@@ -0,0 +1,248 @@
+// Command gen-portalapi generates the typed portal API client methods of the
+// burwoodportal package from the vendored OpenAPI spec.
+//
+// Every operation with an operationId becomes a Client method of that name.
+// Path parameters become string arguments in path order and a JSON request
+// body becomes a body argument. Responses map to return values:
+//   - a component schema: (*Schema, error)
+//   - an array: ([]Item, error), or a per-item callback following
+//     pagination when the operation sets x-paginated
+//   - no content: error
+//
+// Component schema names are the Go model type names in models.go.
+// Operations marked x-go-skip are implemented by hand.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+type spec struct {
+	Paths map[string]map[string]json.RawMessage `json:"paths"`
+}
+
+type operation struct {
+	OperationID string      `json:"operationId"`
+	Summary     string      `json:"summary"`
+	Parameters  []parameter `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema schema `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content map[string]struct {
+			Schema schema `json:"schema"`
+		} `json:"content"`
+	} `json:"responses"`
+	Paginated bool `json:"x-paginated"`
+	Skip      bool `json:"x-go-skip"`
+}
+
+type parameter struct {
+	Name string `json:"name"`
+	In   string `json:"in"`
+}
+
+type schema struct {
+	Ref   string  `json:"$ref"`
+	Type  string  `json:"type"`
+	Items *schema `json:"items"`
+}
+
+var httpMethods = []string{"get", "post", "put", "patch", "delete"}
+
+func main() {
+	specPath := flag.String("spec", "../api/openapi.json", "OpenAPI spec to generate from")
+	outPath := flag.String("out", "api_gen.go", "Go file to write")
+	pkg := flag.String("package", "burwoodportal", "Go package of the generated file")
+	flag.Parse()
+
+	raw, err := ioutil.ReadFile(*specPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var s spec
+	if err := json.Unmarshal(raw, &s); err != nil {
+		log.Fatalf("parsing %s: %v", *specPath, err)
+	}
+
+	code, err := generate(s, *pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(*outPath, code, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generate(s spec, pkg string) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by tools/gen-portalapi from api/openapi.json. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	fmt.Fprintf(&buf, "import \"context\"\n")
+
+	paths := make([]string, 0, len(s.Paths))
+	for path := range s.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		item := s.Paths[path]
+
+		var shared []parameter
+		if raw, ok := item["parameters"]; ok {
+			if err := json.Unmarshal(raw, &shared); err != nil {
+				return nil, fmt.Errorf("%s parameters: %w", path, err)
+			}
+		}
+
+		for _, method := range httpMethods {
+			raw, ok := item[method]
+			if !ok {
+				continue
+			}
+
+			var op operation
+			if err := json.Unmarshal(raw, &op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			if op.Skip {
+				continue
+			}
+			if op.OperationID == "" {
+				return nil, fmt.Errorf("%s %s: missing operationId", method, path)
+			}
+			op.Parameters = append(append([]parameter{}, shared...), op.Parameters...)
+
+			if err := writeOperation(&buf, strings.ToUpper(method), path, op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+		}
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+func writeOperation(buf *bytes.Buffer, method, path string, op operation) error {
+	pathParams := map[string]bool{}
+	for _, p := range op.Parameters {
+		if p.In == "path" {
+			pathParams[p.Name] = true
+		}
+	}
+
+	// Segments in path order, parameters as arguments.
+	args := []string{"ctx context.Context"}
+	var segments []string
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name := segment[1 : len(segment)-1]
+			if !pathParams[name] {
+				return fmt.Errorf("path parameter %s is not declared", name)
+			}
+			args = append(args, name+" string")
+			segments = append(segments, name)
+			continue
+		}
+		segments = append(segments, fmt.Sprintf("%q", segment))
+	}
+
+	body := "nil"
+	if op.RequestBody != nil {
+		content, ok := op.RequestBody.Content["application/json"]
+		if !ok {
+			return fmt.Errorf("request body must be application/json")
+		}
+		bodyType, err := goType(content.Schema)
+		if err != nil {
+			return err
+		}
+		args = append(args, "body "+bodyType)
+		body = "body"
+	}
+
+	var response *schema
+	if r, ok := op.Responses["200"]; ok {
+		if content, ok := r.Content["application/json"]; ok {
+			response = &content.Schema
+		}
+	}
+
+	segmentList := "[]string{" + strings.Join(segments, ", ") + "}"
+	fmt.Fprintf(buf, "\n// %s - %s %s\n", op.OperationID, method, path)
+	if op.Summary != "" {
+		fmt.Fprintf(buf, "// %s\n", op.Summary)
+	}
+
+	switch {
+	case response == nil:
+		fmt.Fprintf(buf, "func (c *Client) %s(%s) error {\n", op.OperationID, strings.Join(args, ", "))
+		fmt.Fprintf(buf, "\t_, err := do[noContent](ctx, c, %q, %s, %s)\n\treturn err\n}\n", method, segmentList, body)
+
+	case response.Type == "array" && op.Paginated:
+		itemType, err := goType(*response.Items)
+		if err != nil {
+			return err
+		}
+		if method != "GET" || body != "nil" {
+			return fmt.Errorf("x-paginated is only supported on GET operations without a body")
+		}
+		fmt.Fprintf(buf, "// Calls fn with each item, following pagination.\n")
+		args = append(args, fmt.Sprintf("fn func(item %s) error", itemType))
+		fmt.Fprintf(buf, "func (c *Client) %s(%s) error {\n", op.OperationID, strings.Join(args, ", "))
+		fmt.Fprintf(buf, "\treturn forEachListItem(ctx, c, %s, fn)\n}\n", segmentList)
+
+	case response.Type == "array":
+		responseType, err := goType(*response)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "func (c *Client) %s(%s) (%s, error) {\n", op.OperationID, strings.Join(args, ", "), responseType)
+		fmt.Fprintf(buf, "\treturn do[%s](ctx, c, %q, %s, %s)\n}\n", responseType, method, segmentList, body)
+
+	default:
+		responseType, err := goType(*response)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "func (c *Client) %s(%s) (*%s, error) {\n", op.OperationID, strings.Join(args, ", "), responseType)
+		fmt.Fprintf(buf, "\tresult, err := do[%s](ctx, c, %q, %s, %s)\n", responseType, method, segmentList, body)
+		fmt.Fprintf(buf, "\tif err != nil {\n\t\treturn nil, err\n\t}\n\n\treturn &result, nil\n}\n")
+	}
+
+	return nil
+}
+
+// goType maps a request or response schema to the Go model type.
+func goType(s schema) (string, error) {
+	switch {
+	case s.Ref != "":
+		const prefix = "#/components/schemas/"
+		if !strings.HasPrefix(s.Ref, prefix) {
+			return "", fmt.Errorf("unsupported $ref %s", s.Ref)
+		}
+		return strings.TrimPrefix(s.Ref, prefix), nil
+	case s.Type == "array" && s.Items != nil:
+		item, err := goType(*s.Items)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	}
+
+	fmt.Fprintf(os.Stderr, "unsupported inline schema %+v\n", s)
+	return "", fmt.Errorf("only component schema references and arrays of them are supported")
+}